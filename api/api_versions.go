@@ -1,20 +1,75 @@
 package api
 
+import (
+	"github.com/codecrafters-io/kafka-starter-go/config"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
+)
+
+type ApiVersionsResponse struct {
+	Header  ResponseHeader
+	Version int16
+	Body    messages.ApiVersionsResponse
+}
+
+// Encode always uses response header v0, even for flexible versions, so that
+// clients can parse the response before knowing which versions are supported.
+func (a *ApiVersionsResponse) Encode(enc *encoder.BinaryEncoder) error {
+	if err := a.Header.Encode(enc); err != nil {
+		return err
+	}
+	return a.Body.Encode(enc, a.Version)
+}
+
+// PrepareAPIVersionsResponse lists every supported API. A request with an
+// unsupported version is answered with v0, which every client can parse, so
+// that it can retry with a version from the list.
 func PrepareAPIVersionsResponse(msg *Message) ApiVersionsResponse {
 	resp := ApiVersionsResponse{
-		Header: ResponseHeader{CorrelationId: msg.Header.CorrelationId},
-		Body: ApiVersionsResponseBody{
-			ErrorCode: msg.Error,
-		},
+		Header:  newResponseHeader(msg),
+		Version: msg.Header.ApiVersion,
+	}
+	if msg.Error == ErrorUnsupportedVersion {
+		resp.Version = 0
 	}
 
-	if msg.Error == NoError {
-		resp.Body.ApiVersions = []ApiVersion{
-			{ApiKey: ApiVersions, MinVersion: 0, MaxVersion: 5},
-			{ApiKey: DescribeTopicPartitions, MinVersion: 0, MaxVersion: 11},
-			{ApiKey: Fetch, MinVersion: 0, MaxVersion: 17},
-		}
+	resp.Body.SetDefaults()
+	resp.Body.ErrorCode = msg.Error
+	specs := SupportedApis()
+	resp.Body.ApiKeys = make([]messages.ApiVersionsResponseApiVersion, 0, len(specs))
+	for _, spec := range specs {
+		resp.Body.ApiKeys = append(resp.Body.ApiKeys, messages.ApiVersionsResponseApiVersion{
+			ApiKey:     spec.ApiKey,
+			MinVersion: spec.MinVersion,
+			MaxVersion: spec.MaxVersion,
+		})
 	}
 
 	return resp
 }
+
+type apiVersionsHandler struct{}
+
+func (apiVersionsHandler) DecodeRequest(dec *decoder.BinaryDecoder, version int16) (any, error) {
+	body := messages.ApiVersionsRequest{}
+	if err := body.Decode(dec, version); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (apiVersionsHandler) Handle(cfg *config.Config, msg *Message) Response {
+	resp := PrepareAPIVersionsResponse(msg)
+	return &resp
+}
+
+func (apiVersionsHandler) ErrorResponse(msg *Message, errorCode ErrorCode) Response {
+	resp := ApiVersionsResponse{
+		Header:  newResponseHeader(msg),
+		Version: msg.Header.ApiVersion,
+	}
+	resp.Body.SetDefaults()
+	resp.Body.ErrorCode = errorCode
+	return &resp
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"log"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/config"
+)
+
+// RunLogCleaner compacts the partitions of topics with the compact cleanup
+// policy, every log.cleaner.backoff.ms until stop is closed.
+func RunLogCleaner(cfg *config.Config, stop <-chan struct{}) {
+	runPeriodically(cfg.LogCleanerBackoffMs, stop, func(now time.Time) {
+		compactLogs(cfg, now)
+	})
+}
+
+func compactLogs(cfg *config.Config, now time.Time) {
+	clusterMetadata := GetClusterMetadata(cfg)
+	for _, topic := range clusterMetadata.GetTopics() {
+		topicConfig, err := cfg.TopicConfig(clusterMetadata.GetTopicConfigs(topic.TopicName))
+		if err != nil {
+			log.Printf("Error reading configs of topic %s: %s", topic.TopicName, err.Error())
+			continue
+		}
+		if !topicConfig.Compact() {
+			continue
+		}
+		for _, partition := range clusterMetadata.GetPartitionByTopicId(topic.TopicUUID) {
+			partitionLog, err := GetPartitionLog(cfg, &clusterMetadata, topic.TopicName, partition.PartitionID)
+			if err != nil {
+				log.Println("Error opening partition log: ", err.Error())
+				continue
+			}
+			result, err := partitionLog.Compact(topicConfig.DeleteRetentionMs, now)
+			if result.SegmentsCleaned > 0 {
+				log.Printf("Compacted %d segments of %s: removed %d records, %d bytes down to %d",
+					result.SegmentsCleaned, partitionLog.Dir(), result.RecordsRemoved, result.BytesBefore, result.BytesAfter)
+			}
+			if err != nil {
+				log.Println("Error compacting partition log: ", err.Error())
+			}
+		}
+	}
+}
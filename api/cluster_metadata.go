@@ -1,41 +1,42 @@
 package api
 
 import (
-	"fmt"
-	"io"
-	"os"
-	"strconv"
+	"log"
+	"path/filepath"
 
+	"github.com/codecrafters-io/kafka-starter-go/config"
+	kafkalog "github.com/codecrafters-io/kafka-starter-go/log"
 	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/record"
 	"github.com/google/uuid"
 )
 
-const clusterMetadataLogFilePathPattern = "/tmp/kraft-combined-logs/%s-%s/00000000000000000000.log"
+// clusterMetadataLogDir is the directory of the cluster metadata log, relative
+// to the metadata log directory.
+const clusterMetadataLogDir = "__cluster_metadata-0"
 
 type ClusterMetadata struct {
-	RecordBatches []RecordBatch
+	RecordBatches []record.RecordBatch
 }
 
-func GetClusterMetadata(topicName string, partitionId int32) ClusterMetadata {
-	res := ClusterMetadata{RecordBatches: make([]RecordBatch, 0)}
-	file, err := os.Open(fmt.Sprintf(clusterMetadataLogFilePathPattern, topicName, strconv.Itoa(int(partitionId))))
-	if err != nil {
-		return res
-	}
-	bytes, err := io.ReadAll(file)
-	if err != nil {
-		return res
-	}
-	dec := &decoder.BinaryDecoder{}
-	dec.Init(bytes)
-	for dec.Remaining() > 0 {
-		recordBatch := RecordBatch{}
-		if err = recordBatch.Decode(dec); err != nil {
-			return res
+// GetClusterMetadata reads the cluster metadata log, which the controller
+// writes, up to the first batch that cannot be decoded. Incomplete batches left
+// at the end by a crash are removed by RecoverLogs at startup.
+func GetClusterMetadata(cfg *config.Config) ClusterMetadata {
+	res := ClusterMetadata{RecordBatches: make([]record.RecordBatch, 0)}
+	err := kafkalog.ScanDir(filepath.Join(cfg.ClusterMetadataLogDir(), clusterMetadataLogDir), func(batch []byte) error {
+		dec := &decoder.BinaryDecoder{}
+		dec.Init(batch)
+		recordBatch := record.RecordBatch{}
+		if err := recordBatch.Decode(dec); err != nil {
+			return err
 		}
 		res.RecordBatches = append(res.RecordBatches, recordBatch)
+		return nil
+	})
+	if err != nil {
+		log.Println("Error reading cluster metadata: ", err.Error())
 	}
-
 	return res
 }
 
@@ -84,3 +85,73 @@ func (c *ClusterMetadata) GetPartitionByTopicId(topicId uuid.UUID) []*PartitionR
 	}
 	return partitions
 }
+
+func (c *ClusterMetadata) GetTopics() []*TopicRecord {
+	var topics []*TopicRecord
+	for _, recordBatch := range c.RecordBatches {
+		for _, record := range recordBatch.Records {
+			var clusterMetadataRecordVal ClusterMetadataRecordValue
+			_ = clusterMetadataRecordVal.DecodeBytes(record.Value)
+			if topicRecord, ok := clusterMetadataRecordVal.Data.(*TopicRecord); ok {
+				topics = append(topics, topicRecord)
+			}
+		}
+	}
+	return topics
+}
+
+// GetBrokers returns the latest registration of every broker, in registration order.
+func (c *ClusterMetadata) GetBrokers() []*RegisterBrokerRecord {
+	var brokers []*RegisterBrokerRecord
+	for _, recordBatch := range c.RecordBatches {
+		for _, record := range recordBatch.Records {
+			var clusterMetadataRecordVal ClusterMetadataRecordValue
+			_ = clusterMetadataRecordVal.DecodeBytes(record.Value)
+			brokerRecord, ok := clusterMetadataRecordVal.Data.(*RegisterBrokerRecord)
+			if !ok {
+				continue
+			}
+			replaced := false
+			for i, broker := range brokers {
+				if broker.BrokerID == brokerRecord.BrokerID {
+					brokers[i] = brokerRecord
+					replaced = true
+				}
+			}
+			if !replaced {
+				brokers = append(brokers, brokerRecord)
+			}
+		}
+	}
+	return brokers
+}
+
+// GetTopicConfigs returns the configs set on a topic, applying ConfigRecords in log order.
+func (c *ClusterMetadata) GetTopicConfigs(topicName string) map[string]string {
+	configs := make(map[string]string)
+	for _, recordBatch := range c.RecordBatches {
+		for _, record := range recordBatch.Records {
+			var clusterMetadataRecordVal ClusterMetadataRecordValue
+			_ = clusterMetadataRecordVal.DecodeBytes(record.Value)
+			configRecord, ok := clusterMetadataRecordVal.Data.(*ConfigRecord)
+			if !ok || configRecord.ResourceType != configResourceTopic || configRecord.ResourceName != topicName {
+				continue
+			}
+			if configRecord.Value == nil {
+				delete(configs, configRecord.Name)
+			} else {
+				configs[configRecord.Name] = *configRecord.Value
+			}
+		}
+	}
+	return configs
+}
+
+func findPartition(partitions []*PartitionRecord, partitionIndex int32) *PartitionRecord {
+	for _, partition := range partitions {
+		if partition.PartitionID == partitionIndex {
+			return partition
+		}
+	}
+	return nil
+}
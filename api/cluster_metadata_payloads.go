@@ -28,14 +28,18 @@ func (c *ClusterMetadataRecordValue) Decode(dec *decoder.BinaryDecoder) error {
 	c.Type = dec.GetInt8()
 	c.Version = dec.GetInt8()
 	switch c.Type {
+	case 0:
+		c.Data = &RegisterBrokerRecord{Version: c.Version}
 	case 2:
 		c.Data = &TopicRecord{}
 	case 3:
 		c.Data = &PartitionRecord{}
+	case 4:
+		c.Data = &ConfigRecord{}
 	case 12:
 		c.Data = &FeatureLevelRecord{}
 	default:
-		return nil
+		return dec.Err()
 	}
 	return c.Data.Decode(dec)
 }
@@ -51,7 +55,7 @@ func (f *FeatureLevelRecord) Decode(dec *decoder.BinaryDecoder) error {
 	f.Name = dec.GetCompactString()
 	f.FeatureLevel = dec.GetInt16()
 	dec.GetEmptyTaggedFieldArray()
-	return nil
+	return dec.Err()
 }
 
 type TopicRecord struct {
@@ -65,7 +69,7 @@ func (t *TopicRecord) Decode(dec *decoder.BinaryDecoder) error {
 	t.TopicName = dec.GetCompactString()
 	t.TopicUUID = dec.GetUUID()
 	dec.GetEmptyTaggedFieldArray()
-	return nil
+	return dec.Err()
 }
 
 type PartitionRecord struct {
@@ -93,11 +97,101 @@ func (p *PartitionRecord) Decode(dec *decoder.BinaryDecoder) error {
 	p.Leader = dec.GetInt32()
 	p.LeaderEpoch = dec.GetInt32()
 	p.PartitionEpoch = dec.GetInt32()
-	directoriesLength := dec.GetCompactArrayLen()
+	directoriesLength := getArrayLen(dec, true)
 	p.Directories = make([]uuid.UUID, directoriesLength)
 	for i := 0; i < directoriesLength; i++ {
 		p.Directories[i] = dec.GetUUID()
 	}
 	dec.GetEmptyTaggedFieldArray()
-	return nil
+	return dec.Err()
+}
+
+// configResourceTopic is the ConfigRecord resource type of topic configs.
+const configResourceTopic = 2
+
+// ConfigRecord sets a config of a resource such as a topic; a nil Value
+// removes it.
+type ConfigRecord struct {
+	ResourceType int8
+	ResourceName string
+	Name         string
+	Value        *string
+}
+
+func (c *ConfigRecord) isClusterMetadataRecordValuePayload() {}
+
+func (c *ConfigRecord) Decode(dec *decoder.BinaryDecoder) error {
+	c.ResourceType = dec.GetInt8()
+	c.ResourceName = dec.GetCompactString()
+	c.Name = dec.GetCompactString()
+	c.Value = dec.GetCompactNullableString()
+	dec.GetEmptyTaggedFieldArray()
+	return dec.Err()
+}
+
+type RegisterBrokerRecord struct {
+	Version              int8
+	BrokerID             int32
+	IsMigratingZkBroker  bool
+	IncarnationID        uuid.UUID
+	BrokerEpoch          int64
+	EndPoints            []BrokerEndpoint
+	Features             []BrokerFeature
+	Rack                 *string
+	Fenced               bool
+	InControlledShutdown bool
+	LogDirs              []uuid.UUID
+}
+
+type BrokerEndpoint struct {
+	Name             string
+	Host             string
+	Port             uint16
+	SecurityProtocol int16
+}
+
+type BrokerFeature struct {
+	Name                string
+	MinSupportedVersion int16
+	MaxSupportedVersion int16
+}
+
+func (r *RegisterBrokerRecord) isClusterMetadataRecordValuePayload() {}
+
+func (r *RegisterBrokerRecord) Decode(dec *decoder.BinaryDecoder) error {
+	r.BrokerID = dec.GetInt32()
+	if r.Version >= 2 {
+		r.IsMigratingZkBroker = dec.GetBool()
+	}
+	r.IncarnationID = dec.GetUUID()
+	r.BrokerEpoch = dec.GetInt64()
+	r.EndPoints = make([]BrokerEndpoint, getArrayLen(dec, true))
+	for i := range r.EndPoints {
+		r.EndPoints[i].Name = dec.GetCompactString()
+		r.EndPoints[i].Host = dec.GetCompactString()
+		r.EndPoints[i].Port = uint16(dec.GetInt16())
+		r.EndPoints[i].SecurityProtocol = dec.GetInt16()
+		dec.GetEmptyTaggedFieldArray()
+	}
+	r.Features = make([]BrokerFeature, getArrayLen(dec, true))
+	for i := range r.Features {
+		r.Features[i].Name = dec.GetCompactString()
+		r.Features[i].MinSupportedVersion = dec.GetInt16()
+		r.Features[i].MaxSupportedVersion = dec.GetInt16()
+		dec.GetEmptyTaggedFieldArray()
+	}
+	r.Rack = dec.GetCompactNullableString()
+	r.Fenced = dec.GetBool()
+	if r.Version >= 1 {
+		r.InControlledShutdown = dec.GetBool()
+	}
+	if r.Version >= 3 {
+		logDirsLength := getArrayLen(dec, true)
+		r.LogDirs = make([]uuid.UUID, logDirsLength)
+		for i := 0; i < logDirsLength; i++ {
+			r.LogDirs[i] = dec.GetUUID()
+		}
+	}
+	dec.GetEmptyTaggedFieldArray()
+	return dec.Err()
 }
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"maps"
+	"path/filepath"
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/config"
+	kafkalog "github.com/codecrafters-io/kafka-starter-go/log"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+	"github.com/codecrafters-io/kafka-starter-go/record"
+	"github.com/google/uuid"
+)
+
+// testTopic is a topic written to the cluster metadata log by testConfig.
+type testTopic struct {
+	name       string
+	id         uuid.UUID
+	partitions int32
+	configs    map[string]string
+}
+
+// testConfig returns a broker config with a log directory of its own, whose
+// cluster metadata log describes topics.
+func testConfig(t *testing.T, topics ...testTopic) *config.Config {
+	t.Helper()
+	cfg := config.Default()
+	cfg.LogDirs = []string{t.TempDir()}
+
+	var values [][]byte
+	for _, topic := range topics {
+		values = append(values, metadataRecord(2, func(enc *encoder.BinaryEncoder) {
+			enc.PutCompactString(topic.name)
+			enc.PutRawBytes(topic.id[:])
+			enc.PutEmptyTaggedFieldArray()
+		}))
+		for partition := int32(0); partition < topic.partitions; partition++ {
+			values = append(values, metadataRecord(3, func(enc *encoder.BinaryEncoder) {
+				enc.PutInt32(partition)
+				enc.PutRawBytes(topic.id[:])
+				enc.PutCompactInt32Array([]int32{cfg.NodeId}) // replicas
+				enc.PutCompactInt32Array([]int32{cfg.NodeId}) // in-sync replicas
+				enc.PutCompactInt32Array(nil)                 // removing replicas
+				enc.PutCompactInt32Array(nil)                 // adding replicas
+				enc.PutInt32(cfg.NodeId)                      // leader
+				enc.PutInt32(0)                               // leader epoch
+				enc.PutInt32(0)                               // partition epoch
+				enc.PutCompactArrayLen(0)                     // directories
+				enc.PutEmptyTaggedFieldArray()
+			}))
+		}
+		for name, value := range topic.configs {
+			values = append(values, configRecord(topic.name, name, &value))
+		}
+	}
+	appendMetadataRecords(t, cfg, values...)
+	return cfg
+}
+
+// metadataRecord returns the value of a cluster metadata record of the given
+// type, with the payload written by put.
+func metadataRecord(recordType int8, put func(enc *encoder.BinaryEncoder)) []byte {
+	enc := &encoder.BinaryEncoder{}
+	enc.Init(nil)
+	enc.PutInt8(1) // frame version
+	enc.PutInt8(recordType)
+	enc.PutInt8(0) // version
+	put(enc)
+	return enc.ToBytes()
+}
+
+// configRecord returns a ConfigRecord setting a topic config, or removing it when value is nil.
+func configRecord(topic, name string, value *string) []byte {
+	return metadataRecord(4, func(enc *encoder.BinaryEncoder) {
+		enc.PutInt8(configResourceTopic)
+		enc.PutCompactString(topic)
+		enc.PutCompactString(name)
+		enc.PutCompactNullableString(value)
+		enc.PutEmptyTaggedFieldArray()
+	})
+}
+
+// appendMetadataRecords appends a batch holding the record values to the
+// cluster metadata log, as the controller would.
+func appendMetadataRecords(t *testing.T, cfg *config.Config, values ...[]byte) {
+	t.Helper()
+	if len(values) == 0 {
+		return
+	}
+	batch := record.RecordBatch{
+		Magic:           2,
+		LastOffsetDelta: int32(len(values) - 1),
+		ProducerId:      -1,
+		ProducerEpoch:   -1,
+		BaseSequence:    -1,
+	}
+	for i, value := range values {
+		r := record.Record{OffsetDelta: int64(i), KeyLength: -1, ValueLength: int64(len(value)), Value: value}
+		r.Length = r.GetEncodedLength()
+		batch.Records = append(batch.Records, r)
+	}
+	enc := &encoder.BinaryEncoder{}
+	enc.Init(nil)
+	if err := batch.Encode(enc); err != nil {
+		t.Fatal(err)
+	}
+
+	defaults, _ := cfg.TopicConfig(nil)
+	l, err := kafkalog.Open(filepath.Join(cfg.ClusterMetadataLogDir(), clusterMetadataLogDir), logConfig(cfg, defaults))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if _, err = l.Append([][]byte{enc.Bytes()}, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetTopicConfigs(t *testing.T) {
+	cfg := testConfig(t,
+		testTopic{name: "foo", id: uuid.New(), partitions: 1, configs: map[string]string{"retention.ms": "1000", "cleanup.policy": "compact"}},
+		testTopic{name: "bar", id: uuid.New(), partitions: 1, configs: map[string]string{"retention.ms": "2000"}},
+	)
+	value := "5000"
+	appendMetadataRecords(t, cfg, configRecord("foo", "retention.ms", &value), configRecord("foo", "cleanup.policy", nil))
+
+	clusterMetadata := GetClusterMetadata(cfg)
+	want := map[string]string{"retention.ms": "5000"}
+	if got := clusterMetadata.GetTopicConfigs("foo"); !maps.Equal(got, want) {
+		t.Fatalf("configs of foo %v, want %v", got, want)
+	}
+	want = map[string]string{"retention.ms": "2000"}
+	if got := clusterMetadata.GetTopicConfigs("bar"); !maps.Equal(got, want) {
+		t.Fatalf("configs of bar %v, want %v", got, want)
+	}
+	if got := clusterMetadata.GetTopicConfigs("baz"); len(got) != 0 {
+		t.Fatalf("configs of an unknown topic %v, want none", got)
+	}
+}
@@ -5,8 +5,44 @@ const NilByte = 0xff
 type ErrorCode = int16
 
 const (
-	NoError                 ErrorCode = 0
-	UnknownTopicOrPartition ErrorCode = 3
-	ErrorUnsupportedVersion ErrorCode = 35
-	ErrorUnknownTopic       ErrorCode = 100
+	ErrorUnknownServer               ErrorCode = -1
+	NoError                          ErrorCode = 0
+	ErrorOffsetOutOfRange            ErrorCode = 1
+	ErrorCorruptMessage              ErrorCode = 2
+	UnknownTopicOrPartition          ErrorCode = 3
+	ErrorInvalidRequiredAcks         ErrorCode = 21
+	ErrorUnsupportedVersion          ErrorCode = 35
+	ErrorInvalidRequest              ErrorCode = 42
+	ErrorUnsupportedForMessageFormat ErrorCode = 43
+	ErrorKafkaStorage                ErrorCode = 56
+	ErrorFetchSessionIdNotFound      ErrorCode = 70
+	ErrorInvalidFetchSessionEpoch    ErrorCode = 71
+	ErrorUnknownTopic                ErrorCode = 100
 )
+
+// ACL operations, as numbered in Kafka's AclOperation. Authorized operations
+// are reported as a bit field with bit n set for the operation numbered n.
+const (
+	aclOperationRead            = 3
+	aclOperationWrite           = 4
+	aclOperationCreate          = 5
+	aclOperationDelete          = 6
+	aclOperationAlter           = 7
+	aclOperationDescribe        = 8
+	aclOperationClusterAction   = 9
+	aclOperationDescribeConfigs = 10
+	aclOperationAlterConfigs    = 11
+	aclOperationIdempotentWrite = 12
+)
+
+// topicAuthorizedOperations are the operations a topic supports, all of which
+// every client is allowed since the broker has no authorizer.
+const topicAuthorizedOperations int32 = 1<<aclOperationRead | 1<<aclOperationWrite |
+	1<<aclOperationCreate | 1<<aclOperationDelete | 1<<aclOperationAlter |
+	1<<aclOperationDescribe | 1<<aclOperationDescribeConfigs | 1<<aclOperationAlterConfigs
+
+// clusterAuthorizedOperations are the operations the cluster supports, all of
+// which every client is allowed for the same reason.
+const clusterAuthorizedOperations int32 = 1<<aclOperationCreate | 1<<aclOperationClusterAction |
+	1<<aclOperationDescribeConfigs | 1<<aclOperationAlterConfigs | 1<<aclOperationIdempotentWrite |
+	1<<aclOperationAlter | 1<<aclOperationDescribe
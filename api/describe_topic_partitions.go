@@ -1,58 +1,98 @@
 package api
 
 import (
-	"github.com/google/uuid"
+	"github.com/codecrafters-io/kafka-starter-go/config"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
 )
 
-func PrepareDescribeTopicPartitionsResponse(msg *Message) DescribeTopicPartitionsResponse {
-	clusterMetadata := GetClusterMetadata("__cluster_metadata", 0)
-	req := msg.RequestBody.(DescribeTopicPartitionsRequestBody)
+type DescribeTopicPartitionsResponse struct {
+	Header  ResponseHeader
+	Version int16
+	Body    messages.DescribeTopicPartitionsResponse
+}
+
+func (r *DescribeTopicPartitionsResponse) Encode(enc *encoder.BinaryEncoder) error {
+	if err := r.Header.Encode(enc); err != nil {
+		return err
+	}
+	return r.Body.Encode(enc, r.Version)
+}
+
+func PrepareDescribeTopicPartitionsResponse(cfg *config.Config, msg *Message) DescribeTopicPartitionsResponse {
+	clusterMetadata := GetClusterMetadata(cfg)
+	req := msg.RequestBody.(messages.DescribeTopicPartitionsRequest)
 	resp := DescribeTopicPartitionsResponse{
-		Header: ResponseHeader{
-			CorrelationId: msg.Header.CorrelationId,
-		},
-		Body: DescribeTopicPartitionsResponseV0ResponseBody{
-			ThrottleTime: 0,
-			Topics:       nil,
-			Cursor:       Cursor{NextCursor: nil},
-		},
+		Header:  newResponseHeader(msg),
+		Version: msg.Header.ApiVersion,
 	}
-	for _, topic := range req.TopicNames {
+	resp.Body.SetDefaults()
+	for _, topic := range req.Topics {
+		name := topic.Name
 		topicRecord := clusterMetadata.GetTopicByName(topic.Name)
 		if topicRecord == nil {
-			resp.Body.Topics = append(resp.Body.Topics, DescribeTopicPartitionsResponseV0Topic{
-				ErrorCode:            UnknownTopicOrPartition,
-				Name:                 topic.Name,
-				ID:                   uuid.UUID{},
-				IsInternal:           0,
-				Partitions:           nil,
-				AuthorizedOperations: 3576, // hardcoded for stage vt6
+			resp.Body.Topics = append(resp.Body.Topics, messages.DescribeTopicPartitionsResponseTopic{
+				ErrorCode:                 UnknownTopicOrPartition,
+				Name:                      &name,
+				TopicAuthorizedOperations: topicAuthorizedOperations,
 			})
 			continue
 		}
 		partitionRecords := clusterMetadata.GetPartitionByTopicId(topicRecord.TopicUUID)
-		partitions := make([]Partition, 0, len(partitionRecords))
+		partitions := make([]messages.DescribeTopicPartitionsResponsePartition, 0, len(partitionRecords))
 		for _, partition := range partitionRecords {
-			partitions = append(partitions, Partition{
-				ErrorCode:       NoError,
-				PartitionIndex:  partition.PartitionID,
-				LeaderID:        partition.Leader,
-				LeaderEpoch:     partition.LeaderEpoch,
-				ReplicaNodes:    partition.Replicas,
-				ISRNodes:        partition.InSyncReplicas,
-				ELRs:            nil,
-				LastKnownELRs:   nil,
-				OffLineReplicas: nil,
+			partitions = append(partitions, messages.DescribeTopicPartitionsResponsePartition{
+				ErrorCode:              NoError,
+				PartitionIndex:         partition.PartitionID,
+				LeaderId:               partition.Leader,
+				LeaderEpoch:            partition.LeaderEpoch,
+				ReplicaNodes:           partition.Replicas,
+				IsrNodes:               partition.InSyncReplicas,
+				EligibleLeaderReplicas: []int32{},
+				LastKnownElr:           []int32{},
 			})
 		}
-		resp.Body.Topics = append(resp.Body.Topics, DescribeTopicPartitionsResponseV0Topic{
-			ErrorCode:            NoError,
-			Name:                 topicRecord.TopicName,
-			ID:                   topicRecord.TopicUUID,
-			IsInternal:           0,
-			Partitions:           partitions,
-			AuthorizedOperations: 3576,
+		resp.Body.Topics = append(resp.Body.Topics, messages.DescribeTopicPartitionsResponseTopic{
+			ErrorCode:                 NoError,
+			Name:                      &name,
+			TopicId:                   topicRecord.TopicUUID,
+			Partitions:                partitions,
+			TopicAuthorizedOperations: topicAuthorizedOperations,
 		})
 	}
 	return resp
 }
+
+type describeTopicPartitionsHandler struct{}
+
+func (describeTopicPartitionsHandler) DecodeRequest(dec *decoder.BinaryDecoder, version int16) (any, error) {
+	body := messages.DescribeTopicPartitionsRequest{}
+	if err := body.Decode(dec, version); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (describeTopicPartitionsHandler) Handle(cfg *config.Config, msg *Message) Response {
+	resp := PrepareDescribeTopicPartitionsResponse(cfg, msg)
+	return &resp
+}
+
+func (describeTopicPartitionsHandler) ErrorResponse(msg *Message, errorCode ErrorCode) Response {
+	req := msg.RequestBody.(messages.DescribeTopicPartitionsRequest)
+	resp := DescribeTopicPartitionsResponse{
+		Header:  newResponseHeader(msg),
+		Version: msg.Header.ApiVersion,
+	}
+	resp.Body.SetDefaults()
+	for _, topic := range req.Topics {
+		name := topic.Name
+		resp.Body.Topics = append(resp.Body.Topics, messages.DescribeTopicPartitionsResponseTopic{
+			ErrorCode:                 errorCode,
+			Name:                      &name,
+			TopicAuthorizedOperations: topicAuthorizedOperations,
+		})
+	}
+	return &resp
+}
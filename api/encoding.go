@@ -0,0 +1,19 @@
+package api
+
+import "github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+
+// Helpers for arrays whose wire format depends on whether the payload uses the
+// flexible (compact) encoding. API messages use the generated types instead.
+
+// getArrayLen reads the length of an array that is never null; a null array is read as empty.
+func getArrayLen(dec *decoder.BinaryDecoder, flexible bool) int {
+	return max(getNullableArrayLen(dec, flexible), 0)
+}
+
+// getNullableArrayLen reads the length of an array, or -1 for a null array.
+func getNullableArrayLen(dec *decoder.BinaryDecoder, flexible bool) int {
+	if flexible {
+		return dec.GetCompactArrayLen()
+	}
+	return dec.GetArrayLen()
+}
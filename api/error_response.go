@@ -0,0 +1,27 @@
+package api
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+// ErrorResponse carries only a top-level error code after the response header. It
+// answers requests for unknown APIs, whose response layout is unknown too.
+type ErrorResponse struct {
+	Header    ResponseHeader
+	ErrorCode int16
+}
+
+func PrepareErrorResponse(msg *Message) ErrorResponse {
+	return ErrorResponse{
+		Header:    newResponseHeader(msg),
+		ErrorCode: msg.Error,
+	}
+}
+
+func (r *ErrorResponse) Encode(enc *encoder.BinaryEncoder) error {
+	if err := r.Header.Encode(enc); err != nil {
+		return err
+	}
+	enc.PutInt16(r.ErrorCode)
+	return nil
+}
@@ -1,51 +1,183 @@
 package api
 
-func PrepareFetchResponse(msg *Message) FetchResponse {
-	resp := FetchResponse{
-		Header: ResponseHeader{CorrelationId: msg.Header.CorrelationId},
-		Body: FetchResponseBody{
-			ThrottleTimeMs: 0,
-			ErrorCode:      NoError,
-			SessionId:      0,
-		},
-	}
-	req := msg.RequestBody.(FetchRequestBody)
-	topicResponses := make([]FetchResponseTopic, len(req.Topics))
+import (
+	"log"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/config"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
+)
+
+type FetchResponse struct {
+	Header  ResponseHeader
+	Version int16
+	Body    messages.FetchResponse
+}
+
+func (r *FetchResponse) Encode(enc *encoder.BinaryEncoder) error {
+	if err := r.Header.Encode(enc); err != nil {
+		return err
+	}
+	return r.Body.Encode(enc, r.Version)
+}
+
+// PrepareFetchResponse blocks for up to MaxWaitMs until MinBytes are available.
+func PrepareFetchResponse(cfg *config.Config, msg *Message) FetchResponse {
+	req := msg.RequestBody.(messages.FetchRequest)
+	ctx, errorCode := fetchSessions.newContext(req)
+	if errorCode != NoError {
+		resp := FetchResponse{
+			Header:  newResponseHeader(msg),
+			Version: msg.Header.ApiVersion,
+		}
+		resp.Body.SetDefaults()
+		resp.Body.ErrorCode = errorCode
+		return resp
+	}
+
+	deadline := time.Now().Add(time.Duration(req.MaxWaitMs) * time.Millisecond)
+	// the topics are resolved once, since only the logs change while the fetch waits
+	clusterMetadata := GetClusterMetadata(cfg)
+	resp, appended := prepareFetchResponse(cfg, &clusterMetadata, newResponseHeader(msg), ctx.request)
+	for !fetchSatisfied(&resp, req.MinBytes) && len(appended) > 0 && time.Now().Before(deadline) {
+		waitForAppend(appended, deadline)
+		resp, appended = prepareFetchResponse(cfg, &clusterMetadata, newResponseHeader(msg), ctx.request)
+	}
+	fetchSessions.finish(ctx, &resp)
+	resp.Version = msg.Header.ApiVersion
+	return resp
+}
+
+// prepareFetchResponse reads the requested partitions once, also returning
+// channels that signal new data in any of them.
+func prepareFetchResponse(cfg *config.Config, clusterMetadata *ClusterMetadata, header ResponseHeader, req messages.FetchRequest) (FetchResponse, []<-chan struct{}) {
+	resp := FetchResponse{Header: header}
+	resp.Body.SetDefaults()
+	resp.Body.ErrorCode = NoError
+	var appended []<-chan struct{}
+	remainingBytes := int(req.MaxBytes)
+	resp.Body.Responses = make([]messages.FetchResponseFetchableTopicResponse, len(req.Topics))
 	for i, topic := range req.Topics {
-		partitionResponses := []FetchResponsePartition{}
-		clusterMetadata := GetClusterMetadata("__cluster_metadata", 0)
-		topicRec := clusterMetadata.GetTopicByID(topic.TopicID)
+		partitionResponses := []messages.FetchResponsePartitionData{}
+		var topicRec *TopicRecord
+		unknownTopicError := ErrorUnknownTopic
+		if topic.Topic != "" {
+			// versions before 13 address topics by name rather than by id
+			topicRec = clusterMetadata.GetTopicByName(topic.Topic)
+			unknownTopicError = UnknownTopicOrPartition
+		} else {
+			topicRec = clusterMetadata.GetTopicByID(topic.TopicId)
+		}
 		if topicRec == nil {
-			partitionResponses = append(partitionResponses, FetchResponsePartition{
-				PartitionIndex:      0,
-				ErrorCode:           ErrorUnknownTopic,
-				HighWatermark:       0,
-				LastStableOffset:    0,
-				LogStartOffset:      0,
-				AbortedTransactions: nil,
-				Records:             nil,
-			})
+			partitionResp := newFetchResponsePartition(0, unknownTopicError)
+			partitionResp.HighWatermark = 0
+			partitionResp.LastStableOffset = 0
+			partitionResp.LogStartOffset = 0
+			partitionResponses = append(partitionResponses, partitionResp)
 		} else {
+			partitionRecs := clusterMetadata.GetPartitionByTopicId(topicRec.TopicUUID)
 			for _, partition := range topic.Partitions {
-				partitionMetadata := GetClusterMetadata(topicRec.TopicName, partition.PartitionIndex)
-				partitionResponses = append(partitionResponses, FetchResponsePartition{
-					PartitionIndex:       partition.PartitionIndex,
-					ErrorCode:            0,
-					HighWatermark:        0,
-					LastStableOffset:     0,
-					LogStartOffset:       0,
-					AbortedTransactions:  nil,
-					PreferredReadReplica: 0,
-					Records:              partitionMetadata.RecordBatches,
-				})
+				if findPartition(partitionRecs, partition.Partition) == nil {
+					partitionResponses = append(partitionResponses, newFetchResponsePartition(partition.Partition, UnknownTopicOrPartition))
+					continue
+				}
+				partitionLog, err := GetPartitionLog(cfg, clusterMetadata, topicRec.TopicName, partition.Partition)
+				if err != nil {
+					log.Println("Error opening partition log: ", err.Error())
+					partitionResponses = append(partitionResponses, newFetchResponsePartition(partition.Partition, ErrorKafkaStorage))
+					continue
+				}
+				// taken before reading so an append in between still wakes the fetch
+				appended = append(appended, partitionLog.Appended())
+				// only the first partition with data may exceed the limits, with a single batch
+				maxBytes := min(int(partition.PartitionMaxBytes), remainingBytes)
+				partitionResp := fetchPartition(partitionLog, partition, maxBytes, remainingBytes == int(req.MaxBytes))
+				remainingBytes -= len(partitionResp.Records)
+				partitionResponses = append(partitionResponses, partitionResp)
 			}
 		}
-		topicResponses[i] = FetchResponseTopic{
-			TopicID:    topic.TopicID,
+		resp.Body.Responses[i] = messages.FetchResponseFetchableTopicResponse{
+			Topic:      topic.Topic,
+			TopicId:    topic.TopicId,
 			Partitions: partitionResponses,
 		}
 	}
-	resp.Body.Responses = topicResponses
 
+	return resp, appended
+}
+
+// newFetchResponsePartition returns the response for a partition with no
+// records and offsets unknown.
+func newFetchResponsePartition(partitionIndex int32, errorCode ErrorCode) messages.FetchResponsePartitionData {
+	resp := messages.FetchResponsePartitionData{}
+	resp.SetDefaults()
+	resp.PartitionIndex = partitionIndex
+	resp.ErrorCode = errorCode
+	resp.HighWatermark = -1
 	return resp
 }
+
+// fetchPartition reads up to maxBytes of the partition log from the batch
+// containing the fetch offset onwards.
+func fetchPartition(partitionLog *PartitionLog, partition messages.FetchRequestFetchPartition, maxBytes int, minOneBatch bool) messages.FetchResponsePartitionData {
+	resp := newFetchResponsePartition(partition.Partition, NoError)
+	logStartOffset, highWatermark := partitionLog.Offsets()
+	resp.HighWatermark = highWatermark
+	resp.LastStableOffset = highWatermark
+	resp.LogStartOffset = logStartOffset
+
+	if partition.FetchOffset < logStartOffset || partition.FetchOffset > highWatermark {
+		resp.ErrorCode = ErrorOffsetOutOfRange
+		return resp
+	}
+	if partition.FetchOffset == highWatermark {
+		return resp
+	}
+	var err error
+	resp.Records, err = partitionLog.Read(partition.FetchOffset, maxBytes, minOneBatch)
+	if err != nil {
+		log.Println("Error reading partition log: ", err.Error())
+		resp.ErrorCode = ErrorKafkaStorage
+	}
+	return resp
+}
+
+type fetchHandler struct{}
+
+func (fetchHandler) DecodeRequest(dec *decoder.BinaryDecoder, version int16) (any, error) {
+	body := messages.FetchRequest{}
+	if err := body.Decode(dec, version); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (fetchHandler) Handle(cfg *config.Config, msg *Message) Response {
+	resp := PrepareFetchResponse(cfg, msg)
+	return &resp
+}
+
+func (fetchHandler) ErrorResponse(msg *Message, errorCode ErrorCode) Response {
+	req := msg.RequestBody.(messages.FetchRequest)
+	resp := FetchResponse{
+		Header:  newResponseHeader(msg),
+		Version: msg.Header.ApiVersion,
+	}
+	resp.Body.SetDefaults()
+	resp.Body.ErrorCode = errorCode
+	resp.Body.Responses = make([]messages.FetchResponseFetchableTopicResponse, len(req.Topics))
+	for i, topic := range req.Topics {
+		partitionResponses := make([]messages.FetchResponsePartitionData, len(topic.Partitions))
+		for j, partition := range topic.Partitions {
+			partitionResponses[j] = newFetchResponsePartition(partition.Partition, errorCode)
+		}
+		resp.Body.Responses[i] = messages.FetchResponseFetchableTopicResponse{
+			Topic:      topic.Topic,
+			TopicId:    topic.TopicId,
+			Partitions: partitionResponses,
+		}
+	}
+	return &resp
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"time"
+)
+
+// A fetch that cannot be answered with at least MinBytes is parked in the
+// purgatory until one of its partitions is appended to or MaxWaitMs expires,
+// after which the response is prepared again from the current logs.
+
+// fetchSatisfied reports whether the response can be sent without waiting:
+// enough bytes were read, or some partition has an error to report.
+func fetchSatisfied(resp *FetchResponse, minBytes int32) bool {
+	recordsLength := 0
+	for _, topic := range resp.Body.Responses {
+		for _, partition := range topic.Partitions {
+			if partition.ErrorCode != NoError {
+				return true
+			}
+			recordsLength += len(partition.Records)
+		}
+	}
+	return recordsLength >= int(minBytes)
+}
+
+// waitForAppend blocks until any of the given partition logs is appended to,
+// returning false if the deadline passes first.
+func waitForAppend(appended []<-chan struct{}, deadline time.Time) bool {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	woken := make(chan struct{})
+	defer close(woken)
+	notify := make(chan struct{}, 1)
+	for _, ch := range appended {
+		go func(ch <-chan struct{}) {
+			select {
+			case <-ch:
+				select {
+				case notify <- struct{}{}:
+				default:
+				}
+			case <-woken:
+			}
+		}(ch)
+	}
+
+	select {
+	case <-notify:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
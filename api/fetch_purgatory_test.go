@@ -0,0 +1,138 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
+	"github.com/google/uuid"
+)
+
+func TestFetchSatisfied(t *testing.T) {
+	partitions := func(partitions ...messages.FetchResponsePartitionData) *FetchResponse {
+		return &FetchResponse{Body: messages.FetchResponse{Responses: []messages.FetchResponseFetchableTopicResponse{{Partitions: partitions}}}}
+	}
+	tests := []struct {
+		name     string
+		resp     *FetchResponse
+		minBytes int32
+		want     bool
+	}{
+		{name: "no partitions", resp: partitions(), minBytes: 0, want: true},
+		{name: "no data", resp: partitions(messages.FetchResponsePartitionData{}), minBytes: 1, want: false},
+		{name: "too little data", resp: partitions(messages.FetchResponsePartitionData{Records: make([]byte, 10)}), minBytes: 11, want: false},
+		{
+			name:     "enough data across partitions",
+			resp:     partitions(messages.FetchResponsePartitionData{Records: make([]byte, 10)}, messages.FetchResponsePartitionData{Records: make([]byte, 1)}),
+			minBytes: 11,
+			want:     true,
+		},
+		{
+			name:     "error",
+			resp:     partitions(messages.FetchResponsePartitionData{}, messages.FetchResponsePartitionData{ErrorCode: ErrorOffsetOutOfRange}),
+			minBytes: 1,
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fetchSatisfied(tt.resp, tt.minBytes); got != tt.want {
+				t.Fatalf("fetchSatisfied returned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitForAppend(t *testing.T) {
+	appended := make(chan struct{})
+	idle := make(chan struct{})
+	start := time.Now()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(appended)
+	}()
+	if !waitForAppend([]<-chan struct{}{idle, appended}, start.Add(10*time.Second)) {
+		t.Fatal("not woken by an append")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("woken after %v", elapsed)
+	}
+
+	start = time.Now()
+	if waitForAppend([]<-chan struct{}{idle}, start.Add(50*time.Millisecond)) {
+		t.Fatal("woken without an append")
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("returned after %v, before the deadline", elapsed)
+	}
+}
+
+func TestFetchWaits(t *testing.T) {
+	tests := []struct {
+		name string
+		// existing is appended before the fetch and late while it waits
+		existing, late bool
+		maxWait        time.Duration
+		wantRecords    bool
+		wantWait       bool // whether the fetch waits out MaxWaitMs
+	}{
+		{name: "min bytes available", existing: true, maxWait: 10 * time.Second, wantRecords: true},
+		{name: "max wait expires", maxWait: 100 * time.Millisecond, wantWait: true},
+		{name: "append wakes the fetch", late: true, maxWait: 10 * time.Second, wantRecords: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topicID := uuid.New()
+			cfg := testConfig(t, testTopic{name: "foo", id: topicID, partitions: 1})
+			clusterMetadata := GetClusterMetadata(cfg)
+			partitionLog, err := GetPartitionLog(cfg, &clusterMetadata, "foo", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			batch := testRecordBatch(t, 1000, "a", "b")
+			if tt.existing {
+				if _, err = partitionLog.Append([][]byte{batch}, false); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.late {
+				go func() {
+					time.Sleep(50 * time.Millisecond)
+					if _, err := partitionLog.Append([][]byte{batch}, false); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+
+			req := &messages.FetchRequest{}
+			req.SetDefaults()
+			req.MaxWaitMs = int32(tt.maxWait.Milliseconds())
+			req.MinBytes = 1
+			req.Topics = []messages.FetchRequestFetchTopic{{
+				TopicId:    topicID,
+				Partitions: []messages.FetchRequestFetchPartition{{Partition: 0, PartitionMaxBytes: 1 << 20}},
+			}}
+			resp := messages.FetchResponse{}
+			start := time.Now()
+			exchange(t, cfg, Fetch, 16, req, &resp)
+			elapsed := time.Since(start)
+
+			if len(resp.Responses) != 1 || len(resp.Responses[0].Partitions) != 1 {
+				t.Fatalf("response %+v does not match the request", resp)
+			}
+			partition := resp.Responses[0].Partitions[0]
+			if partition.ErrorCode != NoError {
+				t.Fatalf("error %d", partition.ErrorCode)
+			}
+			if gotRecords := string(partition.Records) == string(batch); gotRecords != tt.wantRecords {
+				t.Fatalf("records %x, want the batch: %v", partition.Records, tt.wantRecords)
+			}
+			if tt.wantWait && elapsed < tt.maxWait {
+				t.Fatalf("returned after %v, before MaxWaitMs", elapsed)
+			}
+			if !tt.wantWait && elapsed > tt.maxWait/2 {
+				t.Fatalf("returned after %v, want without waiting out MaxWaitMs", elapsed)
+			}
+		})
+	}
+}
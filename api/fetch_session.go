@@ -0,0 +1,225 @@
+package api
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
+	"github.com/google/uuid"
+)
+
+// Incremental fetch sessions (KIP-227): the broker remembers the partitions a
+// consumer fetches so later requests only list what changed, and responses only
+// carry partitions with new data or metadata.
+// https://cwiki.apache.org/confluence/display/KAFKA/KIP-227%3A+Introduce+Incremental+FetchRequests+to+Increase+Partition+Scalability
+
+const (
+	maxFetchSessions        = 1000
+	fetchSessionIdleTimeout = 2 * time.Minute
+)
+
+// Special session epochs sent by clients.
+const (
+	initialFetchSessionEpoch = 0  // create a new session
+	finalFetchSessionEpoch   = -1 // close the session, or fetch without one
+)
+
+type fetchSessionKey struct {
+	topicID   uuid.UUID
+	topicName string // set instead of topicID by fetch versions before 13
+	partition int32
+}
+
+type fetchSessionPartition struct {
+	request          messages.FetchRequestFetchPartition
+	highWatermark    int64
+	lastStableOffset int64
+	logStartOffset   int64
+}
+
+type FetchSession struct {
+	id         int32
+	epoch      int32 // epoch expected in the next request
+	lastUsed   time.Time
+	keys       []fetchSessionKey // in the order partitions were added
+	partitions map[fetchSessionKey]*fetchSessionPartition
+}
+
+type FetchSessionCache struct {
+	mu       sync.Mutex
+	sessions map[int32]*FetchSession
+}
+
+var fetchSessions = &FetchSessionCache{sessions: make(map[int32]*FetchSession)}
+
+// fetchContext is the outcome of matching a fetch request against the session cache.
+type fetchContext struct {
+	session     *FetchSession         // nil for fetches without a session
+	incremental bool                  // whether the response only carries changed partitions
+	request     messages.FetchRequest // the request with every partition the response covers
+}
+
+func (c *FetchSessionCache) newContext(req messages.FetchRequest) (fetchContext, ErrorCode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.evictIdle(now)
+
+	if req.SessionEpoch == finalFetchSessionEpoch || req.SessionEpoch == initialFetchSessionEpoch {
+		delete(c.sessions, req.SessionId)
+		ctx := fetchContext{request: req}
+		if req.SessionEpoch == initialFetchSessionEpoch && len(c.sessions) < maxFetchSessions {
+			ctx.session = c.newSession(now)
+			ctx.session.update(req.Topics, nil)
+		}
+		return ctx, NoError
+	}
+
+	session, ok := c.sessions[req.SessionId]
+	if !ok {
+		return fetchContext{}, ErrorFetchSessionIdNotFound
+	}
+	if session.epoch != req.SessionEpoch {
+		return fetchContext{}, ErrorInvalidFetchSessionEpoch
+	}
+	session.lastUsed = now
+	session.epoch = nextFetchSessionEpoch(session.epoch)
+	session.update(req.Topics, req.ForgottenTopicsData)
+
+	fullReq := req
+	fullReq.Topics = session.topics()
+	return fetchContext{session: session, incremental: true, request: fullReq}, NoError
+}
+
+func (c *FetchSessionCache) newSession(now time.Time) *FetchSession {
+	var id int32
+	for id == 0 || c.sessions[id] != nil {
+		id = rand.Int31()
+	}
+	session := &FetchSession{
+		id:         id,
+		epoch:      nextFetchSessionEpoch(initialFetchSessionEpoch),
+		lastUsed:   now,
+		partitions: make(map[fetchSessionKey]*fetchSessionPartition),
+	}
+	c.sessions[id] = session
+	return session
+}
+
+func (c *FetchSessionCache) evictIdle(now time.Time) {
+	for id, session := range c.sessions {
+		if now.Sub(session.lastUsed) > fetchSessionIdleTimeout {
+			delete(c.sessions, id)
+		}
+	}
+}
+
+// finish records what the response tells the client about each partition and,
+// for incremental fetches, drops partitions the client already knows about.
+func (c *FetchSessionCache) finish(ctx fetchContext, resp *FetchResponse) {
+	if ctx.session == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resp.Body.SessionId = ctx.session.id
+	topics := resp.Body.Responses[:0]
+	for _, topic := range resp.Body.Responses {
+		partitions := topic.Partitions[:0]
+		for _, partition := range topic.Partitions {
+			key := fetchSessionKey{topicID: topic.TopicId, topicName: topic.Topic, partition: partition.PartitionIndex}
+			changed := ctx.session.record(key, &partition)
+			if changed || !ctx.incremental {
+				partitions = append(partitions, partition)
+			}
+		}
+		if len(partitions) > 0 {
+			topic.Partitions = partitions
+			topics = append(topics, topic)
+		}
+	}
+	resp.Body.Responses = topics
+}
+
+// update applies the partitions added or changed in a request, then the forgotten ones.
+func (s *FetchSession) update(topics []messages.FetchRequestFetchTopic, forgotten []messages.FetchRequestForgottenTopic) {
+	for _, topic := range topics {
+		for _, partition := range topic.Partitions {
+			key := fetchSessionKey{topicID: topic.TopicId, topicName: topic.Topic, partition: partition.Partition}
+			if cached, ok := s.partitions[key]; ok {
+				cached.request = partition
+				continue
+			}
+			s.keys = append(s.keys, key)
+			s.partitions[key] = &fetchSessionPartition{
+				request:          partition,
+				highWatermark:    -1,
+				lastStableOffset: -1,
+				logStartOffset:   -1,
+			}
+		}
+	}
+	for _, topic := range forgotten {
+		for _, partition := range topic.Partitions {
+			delete(s.partitions, fetchSessionKey{topicID: topic.TopicId, topicName: topic.Topic, partition: partition})
+		}
+	}
+	keys := s.keys[:0]
+	for _, key := range s.keys {
+		if _, ok := s.partitions[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	s.keys = keys
+}
+
+// topics lists every partition in the session, grouped by topic. Topics are in
+// the order their first partition was added, since partitions added by later
+// requests can belong to topics already in the session.
+func (s *FetchSession) topics() []messages.FetchRequestFetchTopic {
+	type topicKey struct {
+		id   uuid.UUID
+		name string
+	}
+	var topics []messages.FetchRequestFetchTopic
+	indexes := make(map[topicKey]int)
+	for _, key := range s.keys {
+		i, ok := indexes[topicKey{key.topicID, key.topicName}]
+		if !ok {
+			i = len(topics)
+			indexes[topicKey{key.topicID, key.topicName}] = i
+			topics = append(topics, messages.FetchRequestFetchTopic{Topic: key.topicName, TopicId: key.topicID})
+		}
+		topics[i].Partitions = append(topics[i].Partitions, s.partitions[key].request)
+	}
+	return topics
+}
+
+// record stores the partition state sent to the client, reporting whether it
+// differs from what the client was last told.
+func (s *FetchSession) record(key fetchSessionKey, partition *messages.FetchResponsePartitionData) bool {
+	cached, ok := s.partitions[key]
+	if !ok {
+		return true
+	}
+	changed := len(partition.Records) > 0 ||
+		partition.ErrorCode != NoError ||
+		partition.HighWatermark != cached.highWatermark ||
+		partition.LastStableOffset != cached.lastStableOffset ||
+		partition.LogStartOffset != cached.logStartOffset
+	cached.highWatermark = partition.HighWatermark
+	cached.lastStableOffset = partition.LastStableOffset
+	cached.logStartOffset = partition.LogStartOffset
+	return changed
+}
+
+func nextFetchSessionEpoch(epoch int32) int32 {
+	if epoch == math.MaxInt32 {
+		return 1
+	}
+	return epoch + 1
+}
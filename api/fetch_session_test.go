@@ -0,0 +1,262 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
+	"github.com/google/uuid"
+)
+
+// fetchTopics returns topics with the given partitions, all fetched from offset 0.
+func fetchTopics(partitions map[string][]int32) []messages.FetchRequestFetchTopic {
+	var topics []messages.FetchRequestFetchTopic
+	for _, name := range []string{"foo", "bar"} {
+		if indexes, ok := partitions[name]; ok {
+			topic := messages.FetchRequestFetchTopic{Topic: name}
+			for _, index := range indexes {
+				topic.Partitions = append(topic.Partitions, messages.FetchRequestFetchPartition{Partition: index})
+			}
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// newTestSession adds a session fetching foo-0 and foo-1 to the cache.
+func newTestSession(c *FetchSessionCache) *FetchSession {
+	session := c.newSession(time.Now())
+	session.update(fetchTopics(map[string][]int32{"foo": {0, 1}}), nil)
+	return session
+}
+
+func TestFetchSessionCache(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(c *FetchSessionCache) *FetchSession
+		// req returns the request given the session from setup, which may be nil
+		req         func(session *FetchSession) messages.FetchRequest
+		wantError   ErrorCode
+		wantSession bool
+		wantTopics  []string // partitions the response covers, as "topic-partition"
+	}{
+		{
+			name: "without a session",
+			req: func(*FetchSession) messages.FetchRequest {
+				return messages.FetchRequest{SessionEpoch: finalFetchSessionEpoch, Topics: fetchTopics(map[string][]int32{"foo": {0}})}
+			},
+			wantTopics: []string{"foo-0"},
+		},
+		{
+			name: "new session",
+			req: func(*FetchSession) messages.FetchRequest {
+				return messages.FetchRequest{SessionEpoch: initialFetchSessionEpoch, Topics: fetchTopics(map[string][]int32{"foo": {0}})}
+			},
+			wantSession: true,
+			wantTopics:  []string{"foo-0"},
+		},
+		{
+			name:  "incremental",
+			setup: newTestSession,
+			req: func(session *FetchSession) messages.FetchRequest {
+				return messages.FetchRequest{SessionId: session.id, SessionEpoch: 1, Topics: fetchTopics(map[string][]int32{"bar": {0}})}
+			},
+			wantSession: true,
+			wantTopics:  []string{"foo-0", "foo-1", "bar-0"},
+		},
+		{
+			name:  "forgotten topics",
+			setup: newTestSession,
+			req: func(session *FetchSession) messages.FetchRequest {
+				return messages.FetchRequest{
+					SessionId:           session.id,
+					SessionEpoch:        1,
+					Topics:              fetchTopics(map[string][]int32{"bar": {0}}),
+					ForgottenTopicsData: []messages.FetchRequestForgottenTopic{{Topic: "foo", Partitions: []int32{0}}},
+				}
+			},
+			wantSession: true,
+			wantTopics:  []string{"foo-1", "bar-0"},
+		},
+		{
+			name: "unknown session",
+			req: func(*FetchSession) messages.FetchRequest {
+				return messages.FetchRequest{SessionId: 12345, SessionEpoch: 1}
+			},
+			wantError: ErrorFetchSessionIdNotFound,
+		},
+		{
+			name:  "wrong epoch",
+			setup: newTestSession,
+			req: func(session *FetchSession) messages.FetchRequest {
+				return messages.FetchRequest{SessionId: session.id, SessionEpoch: 2}
+			},
+			wantError: ErrorInvalidFetchSessionEpoch,
+		},
+		{
+			name:  "closed session",
+			setup: newTestSession,
+			req: func(session *FetchSession) messages.FetchRequest {
+				return messages.FetchRequest{SessionId: session.id, SessionEpoch: finalFetchSessionEpoch, Topics: fetchTopics(map[string][]int32{"bar": {0}})}
+			},
+			wantTopics: []string{"bar-0"},
+		},
+		{
+			name: "idle session",
+			setup: func(c *FetchSessionCache) *FetchSession {
+				session := newTestSession(c)
+				session.lastUsed = time.Now().Add(-fetchSessionIdleTimeout - time.Second)
+				return session
+			},
+			req: func(session *FetchSession) messages.FetchRequest {
+				return messages.FetchRequest{SessionId: session.id, SessionEpoch: 1}
+			},
+			wantError: ErrorFetchSessionIdNotFound,
+		},
+		{
+			name: "full cache",
+			setup: func(c *FetchSessionCache) *FetchSession {
+				for len(c.sessions) < maxFetchSessions {
+					newTestSession(c)
+				}
+				return nil
+			},
+			req: func(*FetchSession) messages.FetchRequest {
+				return messages.FetchRequest{SessionEpoch: initialFetchSessionEpoch, Topics: fetchTopics(map[string][]int32{"foo": {0}})}
+			},
+			wantTopics: []string{"foo-0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &FetchSessionCache{sessions: make(map[int32]*FetchSession)}
+			var session *FetchSession
+			if tt.setup != nil {
+				session = tt.setup(c)
+			}
+			req := tt.req(session)
+			ctx, errorCode := c.newContext(req)
+			if errorCode != tt.wantError {
+				t.Fatalf("error %d, want %d", errorCode, tt.wantError)
+			}
+			if (ctx.session != nil) != tt.wantSession {
+				t.Fatalf("session %+v, want one: %v", ctx.session, tt.wantSession)
+			}
+			if ctx.session != nil && c.sessions[ctx.session.id] != ctx.session {
+				t.Fatal("session not in the cache")
+			}
+			if session != nil && req.SessionEpoch == finalFetchSessionEpoch && c.sessions[session.id] != nil {
+				t.Fatal("closed session still in the cache")
+			}
+			if ctx.session != nil && ctx.session.epoch != req.SessionEpoch+1 {
+				t.Fatalf("next epoch %d, want %d", ctx.session.epoch, req.SessionEpoch+1)
+			}
+			var topics []string
+			for _, topic := range ctx.request.Topics {
+				for _, partition := range topic.Partitions {
+					topics = append(topics, fmt.Sprintf("%s-%d", topic.Topic, partition.Partition))
+				}
+			}
+			if !slices.Equal(topics, tt.wantTopics) {
+				t.Fatalf("partitions %v, want %v", topics, tt.wantTopics)
+			}
+		})
+	}
+}
+
+// TestIncrementalFetch runs a consumer through a fetch session, checking that
+// responses leave out partitions it already knows about.
+func TestIncrementalFetch(t *testing.T) {
+	cfg := testConfig(t, testTopic{name: "foo", id: uuid.New(), partitions: 2})
+	batch := testRecordBatch(t, 1000, "a", "b")
+	appendTo := func(partition int32) {
+		t.Helper()
+		clusterMetadata := GetClusterMetadata(cfg)
+		partitionLog, err := GetPartitionLog(cfg, &clusterMetadata, "foo", partition)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = partitionLog.Append([][]byte{batch}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appendTo(0)
+
+	var sessionID int32
+	fetch := func(epoch int32, offsets map[int32]int64, forgotten []int32) messages.FetchResponse {
+		t.Helper()
+		req := &messages.FetchRequest{}
+		req.SetDefaults()
+		req.SessionId = sessionID
+		req.SessionEpoch = epoch
+		if len(offsets) > 0 {
+			topic := messages.FetchRequestFetchTopic{Topic: "foo"}
+			for _, partition := range []int32{0, 1} {
+				if offset, ok := offsets[partition]; ok {
+					topic.Partitions = append(topic.Partitions, messages.FetchRequestFetchPartition{Partition: partition, FetchOffset: offset, PartitionMaxBytes: 1 << 20})
+				}
+			}
+			req.Topics = []messages.FetchRequestFetchTopic{topic}
+		}
+		if len(forgotten) > 0 {
+			req.ForgottenTopicsData = []messages.FetchRequestForgottenTopic{{Topic: "foo", Partitions: forgotten}}
+		}
+		resp := messages.FetchResponse{}
+		exchange(t, cfg, Fetch, 12, req, &resp)
+		return resp
+	}
+	// partitions returns the partitions in a response, as "partition:records".
+	partitions := func(resp messages.FetchResponse) []string {
+		var partitions []string
+		for _, topic := range resp.Responses {
+			for _, partition := range topic.Partitions {
+				partitions = append(partitions, fmt.Sprintf("%d:%d", partition.PartitionIndex, len(partition.Records)))
+			}
+		}
+		return partitions
+	}
+	steps := []struct {
+		name      string
+		before    func()
+		epoch     int32
+		offsets   map[int32]int64
+		forgotten []int32
+		want      []string
+		wantError ErrorCode
+	}{
+		{name: "full fetch", epoch: 0, offsets: map[int32]int64{0: 0, 1: 0}, want: []string{"0:" + fmt.Sprint(len(batch)), "1:0"}},
+		{name: "nothing changed", epoch: 1, offsets: map[int32]int64{0: 2}},
+		{name: "one partition changed", before: func() { appendTo(1) }, epoch: 2, want: []string{"1:" + fmt.Sprint(len(batch))}},
+		{name: "epoch reused", epoch: 2, wantError: ErrorInvalidFetchSessionEpoch},
+		{name: "partition forgotten", epoch: 3, offsets: map[int32]int64{1: 2}, forgotten: []int32{1}},
+		{name: "forgotten partition changed", before: func() { appendTo(1) }, epoch: 4},
+	}
+	for _, step := range steps {
+		if step.before != nil {
+			step.before()
+		}
+		resp := fetch(step.epoch, step.offsets, step.forgotten)
+		if resp.ErrorCode != step.wantError {
+			t.Fatalf("%s: error %d, want %d", step.name, resp.ErrorCode, step.wantError)
+		}
+		if step.wantError != NoError {
+			continue
+		}
+		if step.epoch == 0 {
+			sessionID = resp.SessionId
+		}
+		if resp.SessionId != sessionID || sessionID == 0 {
+			t.Fatalf("%s: session %d, want %d", step.name, resp.SessionId, sessionID)
+		}
+		if got := partitions(resp); !slices.Equal(got, step.want) {
+			t.Fatalf("%s: partitions %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	sessionID = 12345
+	if resp := fetch(1, nil, nil); resp.ErrorCode != ErrorFetchSessionIdNotFound {
+		t.Fatalf("error %d for an unknown session, want %d", resp.ErrorCode, ErrorFetchSessionIdNotFound)
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/config"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
+)
+
+// Response is a complete response, header included, ready to be framed.
+type Response interface {
+	Encode(enc *encoder.BinaryEncoder) error
+}
+
+// Handler implements a single API.
+type Handler interface {
+	// DecodeRequest decodes a request body of the given version.
+	DecodeRequest(dec *decoder.BinaryDecoder, version int16) (any, error)
+	// Handle prepares the response, or returns nil when the client expects none.
+	Handle(cfg *config.Config, msg *Message) Response
+	// ErrorResponse answers a decoded request that could not be handled with
+	// errorCode in place of every result, or returns nil when the client expects none.
+	ErrorResponse(msg *Message, errorCode ErrorCode) Response
+}
+
+// ApiSpec describes the range of versions the broker implements for an API.
+type ApiSpec struct {
+	ApiKey     ApiKey
+	MinVersion int16
+	MaxVersion int16
+	// FlexibleVersion is the first version using compact encodings and tagged
+	// fields, or -1 if no supported version is flexible.
+	FlexibleVersion int16
+}
+
+func (s ApiSpec) SupportsVersion(version int16) bool {
+	return version >= s.MinVersion && version <= s.MaxVersion
+}
+
+func (s ApiSpec) IsFlexible(version int16) bool {
+	return s.FlexibleVersion >= 0 && version >= s.FlexibleVersion
+}
+
+type registeredApi struct {
+	Spec    ApiSpec
+	Handler Handler
+}
+
+// apiRegistry lists every API the broker implements. It is the single source of
+// truth for dispatching requests, validating their versions and advertising
+// them in ApiVersions. Version ranges come from the generated message types.
+var apiRegistry = []registeredApi{
+	{generatedSpec(messages.ProduceRequestApiKey, messages.ProduceRequestMinVersion, messages.ProduceRequestMaxVersion, messages.ProduceRequestFlexibleVersion), produceHandler{}},
+	{generatedSpec(messages.FetchRequestApiKey, messages.FetchRequestMinVersion, messages.FetchRequestMaxVersion, messages.FetchRequestFlexibleVersion), fetchHandler{}},
+	{generatedSpec(messages.ListOffsetsRequestApiKey, messages.ListOffsetsRequestMinVersion, messages.ListOffsetsRequestMaxVersion, messages.ListOffsetsRequestFlexibleVersion), listOffsetsHandler{}},
+	{generatedSpec(messages.MetadataRequestApiKey, messages.MetadataRequestMinVersion, messages.MetadataRequestMaxVersion, messages.MetadataRequestFlexibleVersion), metadataHandler{}},
+	{generatedSpec(messages.ApiVersionsRequestApiKey, messages.ApiVersionsRequestMinVersion, messages.ApiVersionsRequestMaxVersion, messages.ApiVersionsRequestFlexibleVersion), apiVersionsHandler{}},
+	{generatedSpec(messages.DescribeTopicPartitionsRequestApiKey, messages.DescribeTopicPartitionsRequestMinVersion, messages.DescribeTopicPartitionsRequestMaxVersion, messages.DescribeTopicPartitionsRequestFlexibleVersion), describeTopicPartitionsHandler{}},
+}
+
+func generatedSpec(apiKey ApiKey, minVersion, maxVersion, flexibleVersion int16) ApiSpec {
+	return ApiSpec{ApiKey: apiKey, MinVersion: minVersion, MaxVersion: maxVersion, FlexibleVersion: flexibleVersion}
+}
+
+func lookupApi(apiKey ApiKey) (registeredApi, bool) {
+	for _, api := range apiRegistry {
+		if api.Spec.ApiKey == apiKey {
+			return api, true
+		}
+	}
+	return registeredApi{}, false
+}
+
+func GetApiSpec(apiKey ApiKey) (ApiSpec, bool) {
+	api, ok := lookupApi(apiKey)
+	return api.Spec, ok
+}
+
+func SupportedApis() []ApiSpec {
+	specs := make([]ApiSpec, len(apiRegistry))
+	for i, api := range apiRegistry {
+		specs[i] = api.Spec
+	}
+	return specs
+}
+
+// HandleRequest dispatches a request to the handler of its API. Requests for
+// unknown APIs get an ErrorResponse; ApiVersions reports its own errors so
+// clients can still negotiate versions.
+func HandleRequest(cfg *config.Config, msg *Message) Response {
+	api, ok := lookupApi(msg.Header.ApiKey)
+	if !ok {
+		resp := PrepareErrorResponse(msg)
+		return &resp
+	}
+	return api.Handler.Handle(cfg, msg)
+}
+
+// HandleError answers a request whose handling failed with errorCode, in the
+// response type of its API.
+func HandleError(msg *Message, errorCode ErrorCode) Response {
+	api, ok := lookupApi(msg.Header.ApiKey)
+	if !ok {
+		msg.Error = errorCode
+		resp := PrepareErrorResponse(msg)
+		return &resp
+	}
+	return api.Handler.ErrorResponse(msg, errorCode)
+}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/config"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
+	"github.com/google/uuid"
+)
+
+// requestBody and responseBody are implemented by the generated message types,
+// which tests use as an independent client-side codec.
+type requestBody interface {
+	Encode(enc *encoder.BinaryEncoder, version int16) error
+}
+
+type responseBody interface {
+	Decode(dec *decoder.BinaryDecoder, version int16) error
+}
+
+const testCorrelationId = 7
+
+// encodeRequest returns the payload of a request as a client would send it,
+// without its size prefix.
+func encodeRequest(t *testing.T, apiKey ApiKey, version int16, req requestBody) []byte {
+	t.Helper()
+	clientId := "test"
+	enc := &encoder.BinaryEncoder{}
+	enc.Init(nil)
+	enc.PutInt16(apiKey)
+	enc.PutInt16(version)
+	enc.PutInt32(testCorrelationId)
+	enc.PutNullableString(&clientId)
+	if RequestHeaderVersion(apiKey, version) >= 2 {
+		enc.PutEmptyTaggedFieldArray()
+	}
+	if err := req.Encode(enc, version); err != nil {
+		t.Fatal(err)
+	}
+	return enc.Bytes()
+}
+
+// handle decodes a request payload and handles it as the server does, returning
+// the encoded response without its size prefix, or nil when there is none.
+func handle(t *testing.T, cfg *config.Config, payload []byte) []byte {
+	t.Helper()
+	msg, err := (&Message{}).FromRawRequest(&RawRequest{Payload: payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := HandleRequest(cfg, msg)
+	if resp == nil {
+		return nil
+	}
+	enc := &encoder.BinaryEncoder{}
+	enc.Init(nil)
+	if err = resp.Encode(enc); err != nil {
+		t.Fatal(err)
+	}
+	return enc.Bytes()
+}
+
+// decodeResponse checks the header of a response and decodes its body into resp,
+// which must take up the rest of the response.
+func decodeResponse(t *testing.T, raw []byte, apiKey ApiKey, version int16, resp responseBody) {
+	t.Helper()
+	dec := &decoder.BinaryDecoder{}
+	dec.Init(raw)
+	if correlationId := dec.GetInt32(); correlationId != testCorrelationId {
+		t.Fatalf("correlation id %d, want %d", correlationId, testCorrelationId)
+	}
+	if ResponseHeaderVersion(apiKey, version) >= 1 {
+		dec.GetTaggedFields()
+	}
+	if err := resp.Decode(dec, version); err != nil {
+		t.Fatalf("decoding response %x: %v", raw, err)
+	}
+	if dec.Remaining() != 0 {
+		t.Fatalf("%d bytes left after the response body in %x", dec.Remaining(), raw)
+	}
+}
+
+// exchange sends a request through the broker's request handling and decodes
+// the response into resp. It reports whether there was a response.
+func exchange(t *testing.T, cfg *config.Config, apiKey ApiKey, version int16, req requestBody, resp responseBody) bool {
+	t.Helper()
+	raw := handle(t, cfg, encodeRequest(t, apiKey, version, req))
+	if raw == nil {
+		return false
+	}
+	decodeResponse(t, raw, apiKey, version, resp)
+	return true
+}
+
+func TestHandleUnknownApi(t *testing.T) {
+	for _, version := range []int16{0, 3} {
+		enc := &encoder.BinaryEncoder{}
+		enc.Init(nil)
+		enc.PutInt16(1000) // api key
+		enc.PutInt16(version)
+		enc.PutInt32(testCorrelationId)
+		enc.PutNullableString(nil)
+		enc.PutRawBytes([]byte{1, 2, 3}) // a body of unknown layout
+
+		raw := handle(t, testConfig(t), enc.Bytes())
+		dec := &decoder.BinaryDecoder{}
+		dec.Init(raw)
+		if correlationId, errorCode := dec.GetInt32(), dec.GetInt16(); correlationId != testCorrelationId || errorCode != ErrorUnsupportedVersion {
+			t.Fatalf("response %x, want correlation id %d and error %d", raw, testCorrelationId, ErrorUnsupportedVersion)
+		}
+		if dec.Err() != nil || dec.Remaining() != 0 {
+			t.Fatalf("response %x is not a header and an error code", raw)
+		}
+	}
+}
+
+func TestFromRawRequestErrors(t *testing.T) {
+	truncated := encodeRequest(t, Produce, 9, produceRequest(1, "foo", 0, []byte{1, 2, 3}))
+	flexible := encodeRequest(t, Metadata, 9, &messages.MetadataRequest{})
+	tests := []struct {
+		name      string
+		payload   []byte
+		wantErr   error // an error closes the connection
+		wantError ErrorCode
+	}{
+		{name: "unsupported version", payload: encodeRequest(t, Metadata, 13, &messages.MetadataRequest{}), wantErr: ErrUnsupportedVersion},
+		{name: "truncated body", payload: truncated[:len(truncated)-2], wantErr: ErrInvalidRequest},
+		{name: "tagged field section cut off", payload: flexible[:len(flexible)-1], wantErr: ErrInvalidRequest},
+		{name: "unsupported ApiVersions version", payload: encodeRequest(t, ApiVersions, 99, &messages.ApiVersionsRequest{}), wantError: ErrorUnsupportedVersion},
+		{name: "valid", payload: encodeRequest(t, Metadata, 12, &messages.MetadataRequest{})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := (&Message{}).FromRawRequest(&RawRequest{Payload: tt.payload})
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && msg.Error != tt.wantError {
+				t.Fatalf("message error %d, want %d", msg.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestApiVersionsUnsupportedVersion(t *testing.T) {
+	raw := handle(t, testConfig(t), encodeRequest(t, ApiVersions, 99, &messages.ApiVersionsRequest{}))
+	// answered with v0 so that the client can parse it and pick a version
+	resp := messages.ApiVersionsResponse{}
+	decodeResponse(t, raw, ApiVersions, 0, &resp)
+	if resp.ErrorCode != ErrorUnsupportedVersion || len(resp.ApiKeys) != len(apiRegistry) {
+		t.Fatalf("response %+v, want error %d and the supported APIs", resp, ErrorUnsupportedVersion)
+	}
+}
+
+// TestHandleError checks that a request whose handling failed is answered in
+// the response type of its API, with the error in place of each result.
+func TestHandleError(t *testing.T) {
+	name := "foo"
+	tests := []struct {
+		apiKey  ApiKey
+		version int16
+		req     requestBody
+		resp    responseBody
+		// errors returns the error codes in the response
+		errors func(resp responseBody) []ErrorCode
+	}{
+		{
+			apiKey: Produce, version: 9, req: produceRequest(1, "foo", 0, nil), resp: &messages.ProduceResponse{},
+			errors: func(resp responseBody) []ErrorCode {
+				return []ErrorCode{resp.(*messages.ProduceResponse).Responses[0].PartitionResponses[0].ErrorCode}
+			},
+		},
+		{
+			apiKey: Fetch, version: 13, resp: &messages.FetchResponse{},
+			req: &messages.FetchRequest{Topics: []messages.FetchRequestFetchTopic{{
+				TopicId:    uuid.New(),
+				Partitions: []messages.FetchRequestFetchPartition{{Partition: 0}},
+			}}},
+			errors: func(resp responseBody) []ErrorCode {
+				fetch := resp.(*messages.FetchResponse)
+				return []ErrorCode{fetch.ErrorCode, fetch.Responses[0].Partitions[0].ErrorCode}
+			},
+		},
+		{
+			apiKey: ListOffsets, version: 7, resp: &messages.ListOffsetsResponse{},
+			req: &messages.ListOffsetsRequest{Topics: []messages.ListOffsetsRequestListOffsetsTopic{{
+				Name:       "foo",
+				Partitions: []messages.ListOffsetsRequestListOffsetsPartition{{PartitionIndex: 0}},
+			}}},
+			errors: func(resp responseBody) []ErrorCode {
+				return []ErrorCode{resp.(*messages.ListOffsetsResponse).Topics[0].Partitions[0].ErrorCode}
+			},
+		},
+		{
+			apiKey: Metadata, version: 12, resp: &messages.MetadataResponse{},
+			req: &messages.MetadataRequest{Topics: []messages.MetadataRequestTopic{{Name: &name}}},
+			errors: func(resp responseBody) []ErrorCode {
+				return []ErrorCode{resp.(*messages.MetadataResponse).Topics[0].ErrorCode}
+			},
+		},
+		{
+			apiKey: ApiVersions, version: 4, req: &messages.ApiVersionsRequest{}, resp: &messages.ApiVersionsResponse{},
+			errors: func(resp responseBody) []ErrorCode {
+				return []ErrorCode{resp.(*messages.ApiVersionsResponse).ErrorCode}
+			},
+		},
+		{
+			apiKey: DescribeTopicPartitions, version: 0, resp: &messages.DescribeTopicPartitionsResponse{},
+			req: &messages.DescribeTopicPartitionsRequest{Topics: []messages.DescribeTopicPartitionsRequestTopicRequest{{Name: "foo"}}},
+			errors: func(resp responseBody) []ErrorCode {
+				return []ErrorCode{resp.(*messages.DescribeTopicPartitionsResponse).Topics[0].ErrorCode}
+			},
+		},
+	}
+	for _, tt := range tests {
+		msg, err := (&Message{}).FromRawRequest(&RawRequest{Payload: encodeRequest(t, tt.apiKey, tt.version, tt.req)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		enc := &encoder.BinaryEncoder{}
+		enc.Init(nil)
+		if err = HandleError(msg, ErrorUnknownServer).Encode(enc); err != nil {
+			t.Fatal(err)
+		}
+		decodeResponse(t, enc.Bytes(), tt.apiKey, tt.version, tt.resp)
+		for _, errorCode := range tt.errors(tt.resp) {
+			if errorCode != ErrorUnknownServer {
+				t.Fatalf("api key %d: error %d, want %d", tt.apiKey, errorCode, ErrorUnknownServer)
+			}
+		}
+	}
+
+	// acks=0 producers expect no response, even to an error
+	msg, err := (&Message{}).FromRawRequest(&RawRequest{Payload: encodeRequest(t, Produce, 9, produceRequest(0, "foo", 0, nil))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := HandleError(msg, ErrorUnknownServer); resp != nil {
+		t.Fatalf("response %+v to a produce with acks=0", resp)
+	}
+}
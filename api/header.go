@@ -8,7 +8,10 @@ import (
 type ApiKey = int16
 
 const (
+	Produce                 ApiKey = 0
 	Fetch                   ApiKey = 1
+	ListOffsets             ApiKey = 2
+	Metadata                ApiKey = 3
 	ApiVersions             ApiKey = 18
 	DescribeTopicPartitions ApiKey = 75
 )
@@ -17,20 +20,72 @@ type RequestHeader struct {
 	ApiKey        ApiKey
 	ApiVersion    int16
 	CorrelationId int32
-	ClientId      string
+	ClientId      *string           // nil when the client sent a null client id
+	TaggedFields  map[uint64][]byte // v2 only; no tags are defined yet
 }
 
-func (r *RequestHeader) DecodeV2(dec *decoder.BinaryDecoder) error {
+// RequestHeaderVersion returns the request header version used by an API
+// version: flexible versions use v2, which adds tagged fields, and the rest v1.
+func RequestHeaderVersion(apiKey ApiKey, apiVersion int16) int16 {
+	if spec, ok := GetApiSpec(apiKey); ok && spec.IsFlexible(apiVersion) {
+		return 2
+	}
+	return 1
+}
+
+// ResponseHeaderVersion returns the response header version used by an API
+// version. ApiVersions always uses v0, since a client parses its response
+// before knowing which versions the broker supports.
+func ResponseHeaderVersion(apiKey ApiKey, apiVersion int16) int16 {
+	if apiKey == ApiVersions {
+		return 0
+	}
+	if spec, ok := GetApiSpec(apiKey); ok && spec.IsFlexible(apiVersion) {
+		return 1
+	}
+	return 0
+}
+
+// Decode reads a request header. The fields of v1 are read first, since the
+// API key and version among them determine whether v2 fields follow.
+func (r *RequestHeader) Decode(dec *decoder.BinaryDecoder) error {
+	if err := r.DecodeV1(dec); err != nil {
+		return err
+	}
+	if RequestHeaderVersion(r.ApiKey, r.ApiVersion) >= 2 {
+		r.TaggedFields = dec.GetTaggedFields()
+	}
+	return dec.Err()
+}
+
+func (r *RequestHeader) DecodeV1(dec *decoder.BinaryDecoder) error {
 	r.ApiKey = dec.GetInt16()
 	r.ApiVersion = dec.GetInt16()
 	r.CorrelationId = dec.GetInt32()
-	r.ClientId = dec.GetString()
-	dec.GetEmptyTaggedFieldArray()
-	return nil
+	r.ClientId = dec.GetNullableString()
+	return dec.Err()
 }
 
 type ResponseHeader struct {
+	Version       int16
 	CorrelationId int32
+	TaggedFields  map[uint64][]byte // v1 only; no tags are defined yet
+}
+
+// newResponseHeader returns the header answering a request, in the version its
+// API version calls for.
+func newResponseHeader(msg *Message) ResponseHeader {
+	return ResponseHeader{
+		Version:       ResponseHeaderVersion(msg.Header.ApiKey, msg.Header.ApiVersion),
+		CorrelationId: msg.Header.CorrelationId,
+	}
+}
+
+func (r *ResponseHeader) Encode(enc *encoder.BinaryEncoder) error {
+	if r.Version >= 1 {
+		return r.EncodeV1(enc)
+	}
+	return r.EncodeV0(enc)
 }
 
 func (r *ResponseHeader) EncodeV0(enc *encoder.BinaryEncoder) error {
@@ -40,6 +95,6 @@ func (r *ResponseHeader) EncodeV0(enc *encoder.BinaryEncoder) error {
 
 func (r *ResponseHeader) EncodeV1(enc *encoder.BinaryEncoder) error {
 	enc.PutInt32(r.CorrelationId)
-	enc.PutEmptyTaggedFieldArray()
+	enc.PutTaggedFields(r.TaggedFields)
 	return nil
 }
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+func TestHeaderVersions(t *testing.T) {
+	tests := []struct {
+		apiKey             ApiKey
+		apiVersion         int16
+		wantRequestHeader  int16
+		wantResponseHeader int16
+	}{
+		{apiKey: Produce, apiVersion: 8, wantRequestHeader: 1, wantResponseHeader: 0},
+		{apiKey: Produce, apiVersion: 9, wantRequestHeader: 2, wantResponseHeader: 1},
+		{apiKey: Fetch, apiVersion: 11, wantRequestHeader: 1, wantResponseHeader: 0},
+		{apiKey: Fetch, apiVersion: 12, wantRequestHeader: 2, wantResponseHeader: 1},
+		{apiKey: ListOffsets, apiVersion: 6, wantRequestHeader: 2, wantResponseHeader: 1},
+		{apiKey: Metadata, apiVersion: 0, wantRequestHeader: 1, wantResponseHeader: 0},
+		{apiKey: DescribeTopicPartitions, apiVersion: 0, wantRequestHeader: 2, wantResponseHeader: 1},
+		// ApiVersions answers with v0 even in flexible versions, so that clients
+		// can parse the response before they know which versions are supported
+		{apiKey: ApiVersions, apiVersion: 2, wantRequestHeader: 1, wantResponseHeader: 0},
+		{apiKey: ApiVersions, apiVersion: 3, wantRequestHeader: 2, wantResponseHeader: 0},
+		// versions newer than the broker's are flexible too, as in Kafka
+		{apiKey: ApiVersions, apiVersion: 99, wantRequestHeader: 2, wantResponseHeader: 0},
+		{apiKey: 1000, apiVersion: 3, wantRequestHeader: 1, wantResponseHeader: 0},
+	}
+	for _, tt := range tests {
+		if got := RequestHeaderVersion(tt.apiKey, tt.apiVersion); got != tt.wantRequestHeader {
+			t.Fatalf("api key %d version %d: request header v%d, want v%d", tt.apiKey, tt.apiVersion, got, tt.wantRequestHeader)
+		}
+		if got := ResponseHeaderVersion(tt.apiKey, tt.apiVersion); got != tt.wantResponseHeader {
+			t.Fatalf("api key %d version %d: response header v%d, want v%d", tt.apiKey, tt.apiVersion, got, tt.wantResponseHeader)
+		}
+	}
+}
+
+func TestRequestHeaderDecode(t *testing.T) {
+	clientId := "client"
+	tests := []struct {
+		name             string
+		apiKey           ApiKey
+		apiVersion       int16
+		clientId         *string
+		taggedFields     map[uint64][]byte
+		wantTaggedFields map[uint64][]byte
+	}{
+		{name: "v1", apiKey: Metadata, apiVersion: 8, clientId: &clientId},
+		{name: "v1 null client id", apiKey: Metadata, apiVersion: 8},
+		{name: "v2", apiKey: Metadata, apiVersion: 9, clientId: &clientId, taggedFields: map[uint64][]byte{}},
+		{
+			name: "v2 tagged fields", apiKey: Metadata, apiVersion: 9, clientId: &clientId,
+			taggedFields: map[uint64][]byte{3: {1}}, wantTaggedFields: map[uint64][]byte{3: {1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := &encoder.BinaryEncoder{}
+			enc.Init(nil)
+			enc.PutInt16(tt.apiKey)
+			enc.PutInt16(tt.apiVersion)
+			enc.PutInt32(testCorrelationId)
+			enc.PutNullableString(tt.clientId)
+			if tt.taggedFields != nil {
+				enc.PutTaggedFields(tt.taggedFields)
+			}
+			enc.PutInt8(1) // the body, which must be left unread
+
+			dec := &decoder.BinaryDecoder{}
+			dec.Init(enc.Bytes())
+			header := RequestHeader{}
+			if err := header.Decode(dec); err != nil {
+				t.Fatal(err)
+			}
+			if header.ApiKey != tt.apiKey || header.ApiVersion != tt.apiVersion || header.CorrelationId != testCorrelationId {
+				t.Fatalf("header %+v", header)
+			}
+			if (header.ClientId == nil) != (tt.clientId == nil) || (header.ClientId != nil && *header.ClientId != *tt.clientId) {
+				t.Fatalf("client id %v, want %v", header.ClientId, tt.clientId)
+			}
+			if len(header.TaggedFields) != len(tt.wantTaggedFields) || !bytes.Equal(header.TaggedFields[3], tt.wantTaggedFields[3]) {
+				t.Fatalf("tagged fields %v, want %v", header.TaggedFields, tt.wantTaggedFields)
+			}
+			if dec.Remaining() != 1 {
+				t.Fatalf("%d bytes left after the header, want the body's 1", dec.Remaining())
+			}
+		})
+	}
+}
+
+func TestResponseHeaderEncode(t *testing.T) {
+	tests := []struct {
+		version int16
+		want    []byte
+	}{
+		{version: 0, want: []byte{0, 0, 0, testCorrelationId}},
+		{version: 1, want: []byte{0, 0, 0, testCorrelationId, 0}},
+	}
+	for _, tt := range tests {
+		enc := &encoder.BinaryEncoder{}
+		enc.Init(nil)
+		header := ResponseHeader{Version: tt.version, CorrelationId: testCorrelationId}
+		if err := header.Encode(enc); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(enc.Bytes(), tt.want) {
+			t.Fatalf("v%d header %x, want %x", tt.version, enc.Bytes(), tt.want)
+		}
+	}
+}
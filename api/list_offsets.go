@@ -0,0 +1,168 @@
+package api
+
+import (
+	"log"
+
+	"github.com/codecrafters-io/kafka-starter-go/config"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
+	"github.com/codecrafters-io/kafka-starter-go/record"
+)
+
+// Special timestamps a ListOffsets partition can ask for instead of a real one.
+const (
+	latestTimestamp        = -1
+	earliestTimestamp      = -2
+	maxTimestamp           = -3
+	earliestLocalTimestamp = -4
+)
+
+type ListOffsetsResponse struct {
+	Header  ResponseHeader
+	Version int16
+	Body    messages.ListOffsetsResponse
+}
+
+func (r *ListOffsetsResponse) Encode(enc *encoder.BinaryEncoder) error {
+	if err := r.Header.Encode(enc); err != nil {
+		return err
+	}
+	return r.Body.Encode(enc, r.Version)
+}
+
+func PrepareListOffsetsResponse(cfg *config.Config, msg *Message) ListOffsetsResponse {
+	resp := ListOffsetsResponse{
+		Header:  newResponseHeader(msg),
+		Version: msg.Header.ApiVersion,
+	}
+	resp.Body.SetDefaults()
+	req := msg.RequestBody.(messages.ListOffsetsRequest)
+	clusterMetadata := GetClusterMetadata(cfg)
+	resp.Body.Topics = make([]messages.ListOffsetsResponseListOffsetsTopicResponse, len(req.Topics))
+	for i, topic := range req.Topics {
+		topicRec := clusterMetadata.GetTopicByName(topic.Name)
+		var partitionRecs []*PartitionRecord
+		if topicRec != nil {
+			partitionRecs = clusterMetadata.GetPartitionByTopicId(topicRec.TopicUUID)
+		}
+		partitionResponses := make([]messages.ListOffsetsResponseListOffsetsPartitionResponse, len(topic.Partitions))
+		for j, partition := range topic.Partitions {
+			partitionResp := &partitionResponses[j]
+			partitionResp.SetDefaults()
+			partitionResp.PartitionIndex = partition.PartitionIndex
+			partitionResp.ErrorCode = NoError
+			partitionRec := findPartition(partitionRecs, partition.PartitionIndex)
+			if partitionRec == nil {
+				partitionResp.ErrorCode = UnknownTopicOrPartition
+				continue
+			}
+			partitionResp.LeaderEpoch = partitionRec.LeaderEpoch
+			partitionLog, err := GetPartitionLog(cfg, &clusterMetadata, topic.Name, partition.PartitionIndex)
+			if err == nil {
+				err = lookupOffset(partitionLog, partition.Timestamp, partitionResp)
+			}
+			if err != nil {
+				log.Println("Error reading partition log: ", err.Error())
+				partitionResp.ErrorCode = ErrorKafkaStorage
+			}
+		}
+		resp.Body.Topics[i] = messages.ListOffsetsResponseListOffsetsTopicResponse{
+			Name:       topic.Name,
+			Partitions: partitionResponses,
+		}
+	}
+
+	return resp
+}
+
+// lookupOffset fills in the offset and timestamp answering a single partition
+// query. Timestamp lookups leave both at -1 when no record matches.
+func lookupOffset(partitionLog *PartitionLog, timestamp int64, resp *messages.ListOffsetsResponseListOffsetsPartitionResponse) error {
+	logStartOffset, highWatermark := partitionLog.Offsets()
+
+	switch timestamp {
+	case earliestTimestamp, earliestLocalTimestamp:
+		resp.Offset = logStartOffset
+		return nil
+	case latestTimestamp:
+		resp.Offset = highWatermark
+		return nil
+	}
+
+	// the indexes lead to the batch holding the record, which is then searched
+	var batch []byte
+	var err error
+	if timestamp == maxTimestamp {
+		batch, err = partitionLog.FindBatchWithMaxTimestamp()
+	} else {
+		batch, err = partitionLog.FindBatchByTimestamp(timestamp)
+	}
+	if err != nil || batch == nil {
+		return err
+	}
+	dec := &decoder.BinaryDecoder{}
+	dec.Init(batch)
+	found := &record.RecordBatch{}
+	if err = found.Decode(dec); err != nil {
+		return err
+	}
+
+	target := timestamp
+	if timestamp == maxTimestamp {
+		target = found.MaxTimestamp
+	}
+	for _, record := range found.Records {
+		recordTimestamp := found.FirstTimestamp + record.TimestampDelta
+		if recordTimestamp >= target {
+			resp.Timestamp = recordTimestamp
+			resp.Offset = found.BaseOffset + record.OffsetDelta
+			resp.LeaderEpoch = found.PartitionLeaderEpoch
+			return nil
+		}
+	}
+	// the batch header claims a timestamp none of its records carry, so fall back to the header
+	resp.Timestamp = found.MaxTimestamp
+	resp.Offset = found.BaseOffset + int64(found.LastOffsetDelta)
+	resp.LeaderEpoch = found.PartitionLeaderEpoch
+	return nil
+}
+
+type listOffsetsHandler struct{}
+
+func (listOffsetsHandler) DecodeRequest(dec *decoder.BinaryDecoder, version int16) (any, error) {
+	body := messages.ListOffsetsRequest{}
+	if err := body.Decode(dec, version); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (listOffsetsHandler) Handle(cfg *config.Config, msg *Message) Response {
+	resp := PrepareListOffsetsResponse(cfg, msg)
+	return &resp
+}
+
+func (listOffsetsHandler) ErrorResponse(msg *Message, errorCode ErrorCode) Response {
+	req := msg.RequestBody.(messages.ListOffsetsRequest)
+	resp := ListOffsetsResponse{
+		Header:  newResponseHeader(msg),
+		Version: msg.Header.ApiVersion,
+	}
+	resp.Body.SetDefaults()
+	resp.Body.Topics = make([]messages.ListOffsetsResponseListOffsetsTopicResponse, len(req.Topics))
+	for i, topic := range req.Topics {
+		partitionResponses := make([]messages.ListOffsetsResponseListOffsetsPartitionResponse, len(topic.Partitions))
+		for j, partition := range topic.Partitions {
+			partitionResp := &partitionResponses[j]
+			partitionResp.SetDefaults()
+			partitionResp.PartitionIndex = partition.PartitionIndex
+			partitionResp.ErrorCode = errorCode
+		}
+		resp.Body.Topics[i] = messages.ListOffsetsResponseListOffsetsTopicResponse{
+			Name:       topic.Name,
+			Partitions: partitionResponses,
+		}
+	}
+	return &resp
+}
@@ -1,9 +1,20 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
 )
 
+var (
+	// ErrUnsupportedVersion is returned by FromRawRequest for a version of a known
+	// API the broker does not implement, whose response it cannot encode.
+	ErrUnsupportedVersion = errors.New("unsupported api version")
+	// ErrInvalidRequest is returned by FromRawRequest when a request body cannot be decoded.
+	ErrInvalidRequest = errors.New("invalid request body")
+)
+
 type Message struct {
 	MessageSize int32
 	Header      RequestHeader
@@ -17,33 +28,32 @@ func (m *Message) FromRawRequest(req *RawRequest) (*Message, error) {
 
 	// Parse the request header
 	var reqHeader RequestHeader
-	err := reqHeader.DecodeV2(dec)
+	err := reqHeader.Decode(dec)
 	if err != nil {
 		return nil, err
 	}
 
 	m.MessageSize = int32(len(req.Payload))
 	m.Header = reqHeader
-	if m.Header.ApiVersion < 0 || m.Header.ApiVersion > 4 {
+	api, ok := lookupApi(reqHeader.ApiKey)
+	if !ok || (reqHeader.ApiKey == ApiVersions && !api.Spec.SupportsVersion(reqHeader.ApiVersion)) {
+		// the body layout is unknown, so leave it undecoded; ApiVersions answers
+		// with v0 so that clients can negotiate a version
 		m.Error = ErrorUnsupportedVersion
+		return m, nil
+	}
+	if !api.Spec.SupportsVersion(reqHeader.ApiVersion) {
+		// there is no response type for the version to carry an error, so Kafka
+		// closes the connection instead
+		return nil, fmt.Errorf("%w: api key %d version %d", ErrUnsupportedVersion, reqHeader.ApiKey, reqHeader.ApiVersion)
 	}
-
 	// Parse the request body
-	switch reqHeader.ApiKey {
-	case DescribeTopicPartitions:
-		reqBody := DescribeTopicPartitionsRequestBody{}
-		err = reqBody.DecodeV0(dec)
-		if err != nil {
-			return nil, err
-		}
-		m.RequestBody = reqBody
-	case Fetch:
-		reqBody := FetchRequestBody{}
-		err = reqBody.DecodeV16(dec)
-		if err != nil {
-			return nil, err
-		}
-		m.RequestBody = reqBody
+	m.RequestBody, err = api.Handler.DecodeRequest(dec, reqHeader.ApiVersion)
+	if err == nil {
+		err = dec.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: api key %d version %d: %w", ErrInvalidRequest, reqHeader.ApiKey, reqHeader.ApiVersion, err)
 	}
 	return m, nil
 }
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/config"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
+)
+
+const metaPropertiesFile = "meta.properties"
+
+// authorizedOperationsOmitted is returned when the client did not ask for authorized operations.
+const authorizedOperationsOmitted = math.MinInt32
+
+type MetadataResponse struct {
+	Header  ResponseHeader
+	Version int16
+	Body    messages.MetadataResponse
+}
+
+func (r *MetadataResponse) Encode(enc *encoder.BinaryEncoder) error {
+	if err := r.Header.Encode(enc); err != nil {
+		return err
+	}
+	return r.Body.Encode(enc, r.Version)
+}
+
+func PrepareMetadataResponse(cfg *config.Config, msg *Message) MetadataResponse {
+	clusterMetadata := GetClusterMetadata(cfg)
+	req := msg.RequestBody.(messages.MetadataRequest)
+	resp := MetadataResponse{
+		Header:  newResponseHeader(msg),
+		Version: msg.Header.ApiVersion,
+	}
+	resp.Body.SetDefaults()
+	resp.Body.ClusterId = getClusterId(cfg)
+
+	for _, broker := range clusterMetadata.GetBrokers() {
+		if broker.Fenced || len(broker.EndPoints) == 0 {
+			continue
+		}
+		resp.Body.Brokers = append(resp.Body.Brokers, messages.MetadataResponseBroker{
+			NodeId: broker.BrokerID,
+			Host:   broker.EndPoints[0].Host,
+			Port:   int32(broker.EndPoints[0].Port),
+			Rack:   broker.Rack,
+		})
+	}
+	if len(resp.Body.Brokers) == 0 {
+		// the cluster metadata log holds no broker registrations, which is the
+		// case for the codecrafters test fixtures, so advertise this broker
+		endpoint := cfg.AdvertisedBrokerListeners()[0]
+		resp.Body.Brokers = []messages.MetadataResponseBroker{
+			{NodeId: cfg.NodeId, Host: endpoint.Host, Port: endpoint.Port},
+		}
+	}
+	resp.Body.ControllerId = resp.Body.Brokers[0].NodeId
+	if req.IncludeClusterAuthorizedOperations {
+		resp.Body.ClusterAuthorizedOperations = clusterAuthorizedOperations
+	}
+
+	authorizedOperations := int32(authorizedOperationsOmitted)
+	if req.IncludeTopicAuthorizedOperations {
+		authorizedOperations = topicAuthorizedOperations
+	}
+
+	// v0 has no null array, an empty one asks for all topics instead
+	if req.Topics == nil || (msg.Header.ApiVersion == 0 && len(req.Topics) == 0) {
+		for _, topicRecord := range clusterMetadata.GetTopics() {
+			resp.Body.Topics = append(resp.Body.Topics, prepareMetadataResponseTopic(&clusterMetadata, topicRecord, authorizedOperations))
+		}
+		return resp
+	}
+
+	for _, topic := range req.Topics {
+		var topicRecord *TopicRecord
+		if topic.Name != nil {
+			topicRecord = clusterMetadata.GetTopicByName(*topic.Name)
+		} else {
+			topicRecord = clusterMetadata.GetTopicByID(topic.TopicId)
+		}
+		if topicRecord == nil {
+			// topics looked up by id, which v10+ allows, are reported as UNKNOWN_TOPIC_ID
+			errorCode := UnknownTopicOrPartition
+			if topic.Name == nil {
+				errorCode = ErrorUnknownTopic
+			}
+			resp.Body.Topics = append(resp.Body.Topics, messages.MetadataResponseTopic{
+				ErrorCode:                 errorCode,
+				Name:                      topic.Name,
+				TopicId:                   topic.TopicId,
+				TopicAuthorizedOperations: authorizedOperations,
+			})
+			continue
+		}
+		resp.Body.Topics = append(resp.Body.Topics, prepareMetadataResponseTopic(&clusterMetadata, topicRecord, authorizedOperations))
+	}
+	return resp
+}
+
+func prepareMetadataResponseTopic(clusterMetadata *ClusterMetadata, topicRecord *TopicRecord, authorizedOperations int32) messages.MetadataResponseTopic {
+	partitionRecords := clusterMetadata.GetPartitionByTopicId(topicRecord.TopicUUID)
+	partitions := make([]messages.MetadataResponsePartition, 0, len(partitionRecords))
+	for _, partition := range partitionRecords {
+		partitions = append(partitions, messages.MetadataResponsePartition{
+			ErrorCode:       NoError,
+			PartitionIndex:  partition.PartitionID,
+			LeaderId:        partition.Leader,
+			LeaderEpoch:     partition.LeaderEpoch,
+			ReplicaNodes:    partition.Replicas,
+			IsrNodes:        partition.InSyncReplicas,
+			OfflineReplicas: nil,
+		})
+	}
+	name := topicRecord.TopicName
+	return messages.MetadataResponseTopic{
+		ErrorCode:                 NoError,
+		Name:                      &name,
+		TopicId:                   topicRecord.TopicUUID,
+		IsInternal:                strings.HasPrefix(name, "__"),
+		Partitions:                partitions,
+		TopicAuthorizedOperations: authorizedOperations,
+	}
+}
+
+// getClusterId reads the cluster id that kafka-storage wrote to meta.properties.
+func getClusterId(cfg *config.Config) *string {
+	file, err := os.Open(filepath.Join(cfg.ClusterMetadataLogDir(), metaPropertiesFile))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	properties, err := config.ParseProperties(file)
+	if err != nil {
+		return nil
+	}
+	for _, property := range properties {
+		if property[0] == "cluster.id" {
+			return &property[1]
+		}
+	}
+	return nil
+}
+
+type metadataHandler struct{}
+
+func (metadataHandler) DecodeRequest(dec *decoder.BinaryDecoder, version int16) (any, error) {
+	body := messages.MetadataRequest{}
+	if err := body.Decode(dec, version); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (metadataHandler) Handle(cfg *config.Config, msg *Message) Response {
+	resp := PrepareMetadataResponse(cfg, msg)
+	return &resp
+}
+
+// ErrorResponse reports the error for each requested topic; a request for all
+// topics gets none, since the topics could not be listed.
+func (metadataHandler) ErrorResponse(msg *Message, errorCode ErrorCode) Response {
+	req := msg.RequestBody.(messages.MetadataRequest)
+	resp := MetadataResponse{
+		Header:  newResponseHeader(msg),
+		Version: msg.Header.ApiVersion,
+	}
+	resp.Body.SetDefaults()
+	for _, topic := range req.Topics {
+		resp.Body.Topics = append(resp.Body.Topics, messages.MetadataResponseTopic{
+			ErrorCode:                 errorCode,
+			Name:                      topic.Name,
+			TopicId:                   topic.TopicId,
+			TopicAuthorizedOperations: authorizedOperationsOmitted,
+		})
+	}
+	return &resp
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
+	"github.com/google/uuid"
+)
+
+func TestMetadata(t *testing.T) {
+	fooID, barID, unknownID := uuid.New(), uuid.New(), uuid.New()
+	name := func(name string) *string { return &name }
+	tests := []struct {
+		name    string
+		version int16
+		topics  []messages.MetadataRequestTopic // nil is a null array
+		// includeAuthorizedOperations asks for both cluster and topic authorized operations
+		includeAuthorizedOperations bool
+		wantTopics                  []string // as "name:error code"
+	}{
+		{name: "v0 empty topics are all topics", version: 0, topics: []messages.MetadataRequestTopic{}, wantTopics: []string{"foo:0", "bar:0"}},
+		{name: "v1 null topics are all topics", version: 1, wantTopics: []string{"foo:0", "bar:0"}},
+		{name: "v1 empty topics are none", version: 1, topics: []messages.MetadataRequestTopic{}},
+		{
+			name: "v4 by name", version: 4,
+			topics:     []messages.MetadataRequestTopic{{Name: name("bar")}, {Name: name("baz")}},
+			wantTopics: []string{"bar:0", "baz:3"},
+		},
+		{name: "v9 by name", version: 9, topics: []messages.MetadataRequestTopic{{Name: name("foo")}}, wantTopics: []string{"foo:0"}},
+		{
+			// names are only nullable in responses from v12
+			name: "v10 by id", version: 10,
+			topics:     []messages.MetadataRequestTopic{{TopicId: fooID}, {TopicId: unknownID}},
+			wantTopics: []string{"foo:0", ":100"},
+		},
+		{
+			name: "v12 by name and id", version: 12,
+			topics:     []messages.MetadataRequestTopic{{Name: name("baz")}, {TopicId: barID}, {TopicId: unknownID}},
+			wantTopics: []string{"baz:3", "bar:0", "<null>:100"},
+		},
+		{name: "v8 without authorized operations", version: 8, wantTopics: []string{"foo:0", "bar:0"}},
+		{name: "v8 with authorized operations", version: 8, includeAuthorizedOperations: true, wantTopics: []string{"foo:0", "bar:0"}},
+		{name: "v11 with authorized operations", version: 11, includeAuthorizedOperations: true, wantTopics: []string{"foo:0", "bar:0"}},
+	}
+	cfg := testConfig(t,
+		testTopic{name: "foo", id: fooID, partitions: 2},
+		testTopic{name: "bar", id: barID, partitions: 1},
+	)
+	ids := map[string]uuid.UUID{"foo": fooID, "bar": barID}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &messages.MetadataRequest{
+				Topics:                             tt.topics,
+				IncludeClusterAuthorizedOperations: tt.includeAuthorizedOperations,
+				IncludeTopicAuthorizedOperations:   tt.includeAuthorizedOperations,
+			}
+			resp := messages.MetadataResponse{}
+			exchange(t, cfg, Metadata, tt.version, req, &resp)
+			wantClusterOperations, wantTopicOperations := int32(math.MinInt32), int32(math.MinInt32)
+			if tt.includeAuthorizedOperations {
+				wantClusterOperations, wantTopicOperations = clusterAuthorizedOperations, topicAuthorizedOperations
+			}
+
+			if len(resp.Brokers) != 1 || resp.Brokers[0].NodeId != cfg.NodeId || resp.Brokers[0].Port != 9092 {
+				t.Fatalf("brokers %+v, want this broker", resp.Brokers)
+			}
+			if tt.version >= 1 && resp.ControllerId != cfg.NodeId {
+				t.Fatalf("controller %d, want %d", resp.ControllerId, cfg.NodeId)
+			}
+			var topics []string
+			for _, topic := range resp.Topics {
+				topicName := "<null>"
+				if topic.Name != nil {
+					topicName = *topic.Name
+				}
+				topics = append(topics, fmt.Sprintf("%s:%d", topicName, topic.ErrorCode))
+
+				wantID := uuid.Nil
+				if tt.version >= 10 && topic.ErrorCode == NoError {
+					wantID = ids[topicName]
+				} else if tt.version >= 10 && topic.ErrorCode == ErrorUnknownTopic {
+					wantID = unknownID
+				}
+				if topic.TopicId != wantID {
+					t.Fatalf("topic %s has id %s, want %s", topicName, topic.TopicId, wantID)
+				}
+				wantPartitions := map[string]int{"foo": 2, "bar": 1}[topicName]
+				if len(topic.Partitions) != wantPartitions {
+					t.Fatalf("topic %s has %d partitions, want %d", topicName, len(topic.Partitions), wantPartitions)
+				}
+				if tt.version >= 8 && topic.TopicAuthorizedOperations != wantTopicOperations {
+					t.Fatalf("topic authorized operations %#x, want %#x", topic.TopicAuthorizedOperations, wantTopicOperations)
+				}
+			}
+			if !slices.Equal(topics, tt.wantTopics) {
+				t.Fatalf("topics %v, want %v", topics, tt.wantTopics)
+			}
+			// cluster authorized operations moved to DescribeCluster in v11
+			if tt.version >= 8 && tt.version <= 10 && resp.ClusterAuthorizedOperations != wantClusterOperations {
+				t.Fatalf("cluster authorized operations %#x, want %#x", resp.ClusterAuthorizedOperations, wantClusterOperations)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/codecrafters-io/kafka-starter-go/config"
+	"github.com/codecrafters-io/kafka-starter-go/log"
+)
+
+// PartitionLog is the segmented log of a topic partition.
+type PartitionLog = log.Log
+
+// ErrPartitionLogsClosed is returned by GetPartitionLog once the broker is shutting down.
+var ErrPartitionLogsClosed = errors.New("partition logs are closed")
+
+// partitionLogs holds every partition log opened since startup, by directory.
+var partitionLogs = struct {
+	sync.Mutex
+	logs   map[string]*PartitionLog
+	closed bool
+}{logs: make(map[string]*PartitionLog)}
+
+// GetPartitionLog returns the log of a partition, opening it on first use.
+// The log rolls segments as the topic's configs in the cluster metadata say,
+// which are applied again on every call so that changes take effect.
+func GetPartitionLog(cfg *config.Config, clusterMetadata *ClusterMetadata, topicName string, partitionId int32) (*PartitionLog, error) {
+	topicConfig, err := cfg.TopicConfig(clusterMetadata.GetTopicConfigs(topicName))
+	if err != nil {
+		return nil, fmt.Errorf("reading configs of topic %s: %w", topicName, err)
+	}
+	dir := partitionDir(cfg, topicName, partitionId)
+
+	partitionLogs.Lock()
+	defer partitionLogs.Unlock()
+	if partitionLogs.closed {
+		return nil, ErrPartitionLogsClosed
+	}
+	if partitionLog, ok := partitionLogs.logs[dir]; ok {
+		partitionLog.SetConfig(logConfig(cfg, topicConfig))
+		return partitionLog, nil
+	}
+	partitionLog, err := log.Open(dir, logConfig(cfg, topicConfig))
+	if err != nil {
+		return nil, err
+	}
+	partitionLogs.logs[dir] = partitionLog
+	return partitionLog, nil
+}
+
+// logConfig returns the settings of a log of a topic with the given configs.
+// Indexes are sized by the broker-wide settings.
+func logConfig(cfg *config.Config, topicConfig config.TopicConfig) log.Config {
+	return log.Config{
+		SegmentBytes:       int64(topicConfig.SegmentBytes),
+		SegmentMs:          topicConfig.SegmentMs,
+		IndexIntervalBytes: int(cfg.LogIndexIntervalBytes),
+		SegmentIndexBytes:  int(cfg.LogIndexSizeMaxBytes),
+	}
+}
+
+// partitionDir returns the directory of a partition: the one already holding
+// it in any of the log directories, or else one in the first log directory.
+func partitionDir(cfg *config.Config, topicName string, partitionId int32) string {
+	name := fmt.Sprintf("%s-%d", topicName, partitionId)
+	for _, logDir := range cfg.LogDirs {
+		dir := filepath.Join(logDir, name)
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+	}
+	return filepath.Join(cfg.LogDirs[0], name)
+}
+
+// ClosePartitionLogs syncs and closes every partition log used since startup,
+// returning how many were closed and the first error encountered. Appends
+// still running finish first, and later ones fail, so nothing is written to
+// the logs once it returns.
+func ClosePartitionLogs() (int, error) {
+	partitionLogs.Lock()
+	partitionLogs.closed = true
+	logs := make([]*PartitionLog, 0, len(partitionLogs.logs))
+	for _, partitionLog := range partitionLogs.logs {
+		logs = append(logs, partitionLog)
+	}
+	partitionLogs.Unlock()
+
+	closed := 0
+	var firstErr error
+	for _, partitionLog := range logs {
+		if err := partitionLog.Close(); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("closing %s: %w", partitionLog.Dir(), err)
+			}
+			continue
+		}
+		closed++
+	}
+	return closed, firstErr
+}
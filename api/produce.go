@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"log"
+
+	"github.com/codecrafters-io/kafka-starter-go/config"
+	kafkalog "github.com/codecrafters-io/kafka-starter-go/log"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
+	"github.com/codecrafters-io/kafka-starter-go/record"
+)
+
+type ProduceResponse struct {
+	Header  ResponseHeader
+	Version int16
+	Body    messages.ProduceResponse
+}
+
+func (r *ProduceResponse) Encode(enc *encoder.BinaryEncoder) error {
+	if err := r.Header.Encode(enc); err != nil {
+		return err
+	}
+	return r.Body.Encode(enc, r.Version)
+}
+
+func PrepareProduceResponse(cfg *config.Config, msg *Message) ProduceResponse {
+	resp := ProduceResponse{
+		Header:  newResponseHeader(msg),
+		Version: msg.Header.ApiVersion,
+	}
+	resp.Body.SetDefaults()
+	req := msg.RequestBody.(messages.ProduceRequest)
+	clusterMetadata := GetClusterMetadata(cfg)
+	resp.Body.Responses = make([]messages.ProduceResponseTopicProduceResponse, len(req.TopicData))
+	for i, topic := range req.TopicData {
+		topicRec := clusterMetadata.GetTopicByName(topic.Name)
+		var partitionRecs []*PartitionRecord
+		if topicRec != nil {
+			partitionRecs = clusterMetadata.GetPartitionByTopicId(topicRec.TopicUUID)
+		}
+		partitionResponses := make([]messages.ProduceResponsePartitionProduceResponse, len(topic.PartitionData))
+		for j, partition := range topic.PartitionData {
+			partitionResp := &partitionResponses[j]
+			partitionResp.SetDefaults()
+			partitionResp.Index = partition.Index
+			partitionResp.ErrorCode = NoError
+			partitionResp.BaseOffset = -1
+			if topicRec == nil || findPartition(partitionRecs, partition.Index) == nil {
+				partitionResp.ErrorCode = UnknownTopicOrPartition
+				continue
+			}
+			if req.Acks != 0 && req.Acks != 1 && req.Acks != -1 {
+				partitionResp.ErrorCode = ErrorInvalidRequiredAcks
+				continue
+			}
+			batches, errorCode := validateRecordBatches(partition.Records)
+			if errorCode != NoError {
+				partitionResp.ErrorCode = errorCode
+				continue
+			}
+			partitionLog, err := GetPartitionLog(cfg, &clusterMetadata, topic.Name, partition.Index)
+			if err != nil {
+				log.Println("Error opening partition log: ", err.Error())
+				partitionResp.ErrorCode = ErrorKafkaStorage
+				continue
+			}
+			baseOffset, err := partitionLog.Append(batches, req.Acks == -1)
+			if errors.Is(err, kafkalog.ErrInvalidBatch) {
+				partitionResp.ErrorCode = ErrorCorruptMessage
+				continue
+			}
+			if err != nil {
+				log.Println("Error appending to partition log: ", err.Error())
+				partitionResp.ErrorCode = ErrorKafkaStorage
+				continue
+			}
+			partitionResp.BaseOffset = baseOffset
+			partitionResp.LogStartOffset, _ = partitionLog.Offsets()
+		}
+		resp.Body.Responses[i] = messages.ProduceResponseTopicProduceResponse{
+			Name:               topic.Name,
+			PartitionResponses: partitionResponses,
+		}
+	}
+
+	return resp
+}
+
+// validateRecordBatches checks that the produced records consist of whole v2
+// record batches that decode with valid checksums.
+func validateRecordBatches(records []byte) ([][]byte, ErrorCode) {
+	batches, consumed := record.SplitBatches(records)
+	if len(batches) == 0 || consumed != len(records) {
+		return nil, ErrorCorruptMessage
+	}
+	for _, batch := range batches {
+		err := record.ValidateBatch(batch)
+		if errors.Is(err, record.ErrUnsupportedForMessageFormat) {
+			return nil, ErrorUnsupportedForMessageFormat
+		}
+		if err != nil {
+			return nil, ErrorCorruptMessage
+		}
+	}
+	return batches, NoError
+}
+
+type produceHandler struct{}
+
+func (produceHandler) DecodeRequest(dec *decoder.BinaryDecoder, version int16) (any, error) {
+	body := messages.ProduceRequest{}
+	if err := body.Decode(dec, version); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (produceHandler) Handle(cfg *config.Config, msg *Message) Response {
+	resp := PrepareProduceResponse(cfg, msg)
+	if msg.RequestBody.(messages.ProduceRequest).Acks == 0 {
+		// the producer does not wait for a response when acks=0
+		return nil
+	}
+	return &resp
+}
+
+func (produceHandler) ErrorResponse(msg *Message, errorCode ErrorCode) Response {
+	req := msg.RequestBody.(messages.ProduceRequest)
+	if req.Acks == 0 {
+		return nil
+	}
+	resp := ProduceResponse{
+		Header:  newResponseHeader(msg),
+		Version: msg.Header.ApiVersion,
+	}
+	resp.Body.SetDefaults()
+	resp.Body.Responses = make([]messages.ProduceResponseTopicProduceResponse, len(req.TopicData))
+	for i, topic := range req.TopicData {
+		partitionResponses := make([]messages.ProduceResponsePartitionProduceResponse, len(topic.PartitionData))
+		for j, partition := range topic.PartitionData {
+			partitionResp := &partitionResponses[j]
+			partitionResp.SetDefaults()
+			partitionResp.Index = partition.Index
+			partitionResp.ErrorCode = errorCode
+			partitionResp.BaseOffset = -1
+		}
+		resp.Body.Responses[i] = messages.ProduceResponseTopicProduceResponse{
+			Name:               topic.Name,
+			PartitionResponses: partitionResponses,
+		}
+	}
+	return &resp
+}
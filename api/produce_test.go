@@ -0,0 +1,182 @@
+package api
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
+	"github.com/codecrafters-io/kafka-starter-go/record"
+	"github.com/google/uuid"
+)
+
+// testRecordBatch returns an uncompressed batch of keyless records with the given values.
+func testRecordBatch(t *testing.T, timestamp int64, values ...string) []byte {
+	t.Helper()
+	batch := record.RecordBatch{
+		Magic:           2,
+		LastOffsetDelta: int32(len(values) - 1),
+		FirstTimestamp:  timestamp,
+		MaxTimestamp:    timestamp,
+		ProducerId:      -1,
+		ProducerEpoch:   -1,
+		BaseSequence:    -1,
+	}
+	for i, value := range values {
+		r := record.Record{OffsetDelta: int64(i), KeyLength: -1, ValueLength: int64(len(value)), Value: []byte(value)}
+		r.Length = r.GetEncodedLength()
+		batch.Records = append(batch.Records, r)
+	}
+	enc := &encoder.BinaryEncoder{}
+	enc.Init(nil)
+	if err := batch.Encode(enc); err != nil {
+		t.Fatal(err)
+	}
+	return enc.Bytes()
+}
+
+func produceRequest(acks int16, topic string, partition int32, records []byte) *messages.ProduceRequest {
+	return &messages.ProduceRequest{
+		Acks:      acks,
+		TimeoutMs: 1000,
+		TopicData: []messages.ProduceRequestTopicProduceData{{
+			Name:          topic,
+			PartitionData: []messages.ProduceRequestPartitionProduceData{{Index: partition, Records: records}},
+		}},
+	}
+}
+
+func TestProduce(t *testing.T) {
+	valid := func(t *testing.T) []byte { return testRecordBatch(t, 1000, "a", "b", "c") }
+	tests := []struct {
+		name      string
+		version   int16
+		acks      int16
+		topic     string
+		partition int32
+		records   func(t *testing.T) []byte
+		wantError int16
+	}{
+		{name: "v3", version: 3, acks: 1, topic: "foo", records: valid},
+		{name: "v8", version: 8, acks: 1, topic: "foo", partition: 1, records: valid},
+		{name: "v9", version: 9, acks: 1, topic: "foo", records: valid},
+		{name: "v11", version: 11, acks: 1, topic: "foo", records: valid},
+		{name: "acks=-1", version: 11, acks: -1, topic: "foo", records: valid},
+		{name: "acks=0", version: 11, acks: 0, topic: "foo", records: valid},
+		{name: "invalid acks", version: 11, acks: 2, topic: "foo", records: valid, wantError: ErrorInvalidRequiredAcks},
+		{name: "unknown topic", version: 11, acks: 1, topic: "bar", records: valid, wantError: UnknownTopicOrPartition},
+		{name: "unknown topic v3", version: 3, acks: 1, topic: "bar", records: valid, wantError: UnknownTopicOrPartition},
+		{name: "unknown partition", version: 11, acks: 1, topic: "foo", partition: 2, records: valid, wantError: UnknownTopicOrPartition},
+		{
+			name: "corrupt batch", version: 11, acks: 1, topic: "foo", wantError: ErrorCorruptMessage,
+			records: func(t *testing.T) []byte {
+				raw := valid(t)
+				raw[len(raw)-1] ^= 0xff
+				return raw
+			},
+		},
+		{
+			name: "partial batch", version: 11, acks: 1, topic: "foo", wantError: ErrorCorruptMessage,
+			records: func(t *testing.T) []byte {
+				raw := valid(t)
+				return raw[:len(raw)-1]
+			},
+		},
+		{
+			name: "old message format", version: 11, acks: 1, topic: "foo", wantError: ErrorUnsupportedForMessageFormat,
+			records: func(t *testing.T) []byte {
+				raw := valid(t)
+				raw[16] = 1 // magic
+				return raw
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t, testTopic{name: "foo", id: uuid.New(), partitions: 2})
+			records := tt.records(t)
+
+			// a second produce to the same partition gets the offsets after the first
+			for _, wantBaseOffset := range []int64{0, 3} {
+				resp := messages.ProduceResponse{}
+				req := produceRequest(tt.acks, tt.topic, tt.partition, records)
+				if !exchange(t, cfg, Produce, tt.version, req, &resp) {
+					if tt.acks != 0 {
+						t.Fatal("no response")
+					}
+					continue
+				}
+				if tt.acks == 0 {
+					t.Fatal("responded to a produce with acks=0")
+				}
+				if len(resp.Responses) != 1 || resp.Responses[0].Name != tt.topic || len(resp.Responses[0].PartitionResponses) != 1 {
+					t.Fatalf("response %+v does not match the request", resp)
+				}
+				partition := resp.Responses[0].PartitionResponses[0]
+				if partition.Index != tt.partition || partition.ErrorCode != tt.wantError {
+					t.Fatalf("partition %d error %d, want partition %d error %d", partition.Index, partition.ErrorCode, tt.partition, tt.wantError)
+				}
+				if tt.wantError != NoError {
+					if partition.BaseOffset != -1 {
+						t.Fatalf("base offset %d for a failed produce, want -1", partition.BaseOffset)
+					}
+					continue
+				}
+				if partition.BaseOffset != wantBaseOffset {
+					t.Fatalf("base offset %d, want %d", partition.BaseOffset, wantBaseOffset)
+				}
+				if tt.version >= 5 && partition.LogStartOffset != 0 {
+					t.Fatalf("log start offset %d, want 0", partition.LogStartOffset)
+				}
+			}
+
+			if tt.wantError != NoError {
+				return
+			}
+			clusterMetadata := GetClusterMetadata(cfg)
+			partitionLog, err := GetPartitionLog(cfg, &clusterMetadata, tt.topic, tt.partition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if start, next := partitionLog.Offsets(); start != 0 || next != 6 {
+				t.Fatalf("log offsets %d to %d, want 0 to 6", start, next)
+			}
+		})
+	}
+}
+
+// TestProduceRollsOnTopicConfigs checks that partition logs roll segments at
+// the topic's segment.bytes, picking up changes to it.
+func TestProduceRollsOnTopicConfigs(t *testing.T) {
+	cfg := testConfig(t, testTopic{name: "foo", id: uuid.New(), partitions: 1, configs: map[string]string{"segment.bytes": "100"}})
+	produce := func(count int) {
+		t.Helper()
+		for i := 0; i < count; i++ {
+			resp := messages.ProduceResponse{}
+			exchange(t, cfg, Produce, 11, produceRequest(1, "foo", 0, testRecordBatch(t, 1000, "a")), &resp)
+			if errorCode := resp.Responses[0].PartitionResponses[0].ErrorCode; errorCode != NoError {
+				t.Fatalf("produce failed with error %d", errorCode)
+			}
+		}
+	}
+	segments := func() int {
+		t.Helper()
+		paths, err := filepath.Glob(filepath.Join(partitionDir(cfg, "foo", 0), "*.log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(paths)
+	}
+
+	// a batch is over half of segment.bytes, so every one gets a segment of its own
+	produce(3)
+	if got := segments(); got != 3 {
+		t.Fatalf("%d segments, want 3", got)
+	}
+	segmentBytes := "1073741824"
+	appendMetadataRecords(t, cfg, configRecord("foo", "segment.bytes", &segmentBytes))
+	produce(2)
+	if got := segments(); got != 3 {
+		t.Fatalf("%d segments after raising segment.bytes, want 3", got)
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/config"
+	kafkalog "github.com/codecrafters-io/kafka-starter-go/log"
+)
+
+// cleanShutdownFile is written to every log directory once its logs have been
+// synced at shutdown, and removed at startup. Without it, the logs are
+// recovered before the broker starts serving.
+const cleanShutdownFile = ".kafka_cleanshutdown"
+
+// RecoverLogs recovers the logs of every log directory that was not shut down
+// cleanly, including the cluster metadata log: the active segment of each is
+// checked and truncated at the first incomplete or corrupt batch, and its
+// indexes are rebuilt.
+func RecoverLogs(cfg *config.Config) error {
+	for _, logDir := range allLogDirs(cfg) {
+		marker := filepath.Join(logDir, cleanShutdownFile)
+		err := os.Remove(marker)
+		if err == nil {
+			log.Printf("Skipping recovery of %s, which was shut down cleanly", logDir)
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		if err = recoverLogDir(cfg, logDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func recoverLogDir(cfg *config.Config, logDir string) error {
+	entries, err := os.ReadDir(logDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// recovery appends nothing, so the logs need not roll as their topics say
+	defaults, _ := cfg.TopicConfig(nil)
+	recovered := 0
+	for _, entry := range entries {
+		if !entry.IsDir() || !isPartitionDirName(entry.Name()) {
+			continue
+		}
+		dir := filepath.Join(logDir, entry.Name())
+		partitionLog, err := kafkalog.Open(dir, logConfig(cfg, defaults))
+		if err != nil {
+			return err
+		}
+		truncated, err := partitionLog.Recover()
+		if err = errors.Join(err, partitionLog.Close()); err != nil {
+			return fmt.Errorf("recovering %s: %w", dir, err)
+		}
+		if truncated > 0 {
+			log.Printf("Truncated %d bytes of incomplete or corrupt batches from the end of %s", truncated, dir)
+		}
+		recovered++
+	}
+	log.Printf("Recovered %d logs in %s after an unclean shutdown", recovered, logDir)
+	return nil
+}
+
+// isPartitionDirName reports whether name is that of a partition directory,
+// which is the topic name followed by a dash and the partition index.
+func isPartitionDirName(name string) bool {
+	i := strings.LastIndexByte(name, '-')
+	if i <= 0 {
+		return false
+	}
+	partition, err := strconv.ParseInt(name[i+1:], 10, 32)
+	return err == nil && partition >= 0
+}
+
+// MarkCleanShutdown writes the clean shutdown marker to every log directory,
+// which must only be done once all partition logs have been synced.
+func MarkCleanShutdown(cfg *config.Config) error {
+	for _, logDir := range allLogDirs(cfg) {
+		if _, err := os.Stat(logDir); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(logDir, cleanShutdownFile), nil, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allLogDirs returns the log directories along with the cluster metadata log
+// directory, if it is separate.
+func allLogDirs(cfg *config.Config) []string {
+	logDirs := cfg.LogDirs
+	for _, logDir := range logDirs {
+		if logDir == cfg.ClusterMetadataLogDir() {
+			return logDirs
+		}
+	}
+	return append(logDirs[:len(logDirs):len(logDirs)], cfg.ClusterMetadataLogDir())
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"log"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/config"
+)
+
+// RunLogRetention deletes the segments that the retention settings of topics
+// with the delete cleanup policy no longer keep, every
+// log.retention.check.interval.ms until stop is closed.
+func RunLogRetention(cfg *config.Config, stop <-chan struct{}) {
+	runPeriodically(cfg.LogRetentionCheckIntervalMs, stop, func(now time.Time) {
+		applyLogRetention(cfg, now)
+	})
+}
+
+// runPeriodically calls task every intervalMs until stop is closed.
+func runPeriodically(intervalMs int64, stop <-chan struct{}, task func(now time.Time)) {
+	ticker := time.NewTicker(time.Duration(intervalMs) * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			task(now)
+		}
+	}
+}
+
+func applyLogRetention(cfg *config.Config, now time.Time) {
+	clusterMetadata := GetClusterMetadata(cfg)
+	for _, topic := range clusterMetadata.GetTopics() {
+		topicConfig, err := cfg.TopicConfig(clusterMetadata.GetTopicConfigs(topic.TopicName))
+		if err != nil {
+			log.Printf("Error reading configs of topic %s: %s", topic.TopicName, err.Error())
+			continue
+		}
+		if !topicConfig.Delete() {
+			continue
+		}
+		for _, partition := range clusterMetadata.GetPartitionByTopicId(topic.TopicUUID) {
+			partitionLog, err := GetPartitionLog(cfg, &clusterMetadata, topic.TopicName, partition.PartitionID)
+			if err != nil {
+				log.Println("Error opening partition log: ", err.Error())
+				continue
+			}
+			deleted, err := partitionLog.ApplyRetention(topicConfig.RetentionMs, topicConfig.RetentionBytes, now)
+			for _, segment := range deleted {
+				log.Printf("Deleted segment %d of %s with offsets %d to %d (%d bytes) due to %s",
+					segment.BaseOffset, partitionLog.Dir(), segment.BaseOffset, segment.NextOffset-1, segment.Size, segment.Reason)
+			}
+			if len(deleted) > 0 {
+				logStartOffset, _ := partitionLog.Offsets()
+				log.Printf("Log start offset of %s is now %d", partitionLog.Dir(), logStartOffset)
+			}
+			if err != nil {
+				log.Println("Error applying log retention: ", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/config"
+)
+
+// loadConfig reads the configuration named on the command line, which takes the
+// same arguments as kafka-server-start:
+//
+//	server.properties [--override key=value]...
+//
+// Without a properties file the defaults are used.
+func loadConfig(args []string) (*config.Config, error) {
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	var overrides [][2]string
+	flags.Func("override", "a `key=value` property taking precedence over server.properties; may be repeated", func(property string) error {
+		key, value, found := strings.Cut(property, "=")
+		if !found {
+			return fmt.Errorf("%q is not of the form key=value", property)
+		}
+		overrides = append(overrides, [2]string{key, value})
+		return nil
+	})
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := config.Default()
+	if flags.NArg() > 0 {
+		var err error
+		if cfg, err = config.Load(flags.Arg(0)); err != nil {
+			return nil, err
+		}
+		// overrides usually follow the properties file
+		if err = flags.Parse(flags.Args()[1:]); err != nil {
+			return nil, err
+		}
+		if flags.NArg() > 0 {
+			return nil, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+		}
+	}
+	for _, override := range overrides {
+		if err := cfg.Set(override[0], override[1]); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, cfg.Validate()
+}
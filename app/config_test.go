@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	properties := filepath.Join(t.TempDir(), "server.properties")
+	err := os.WriteFile(properties, []byte("node.id=2\nlog.dirs=/data\nlog.retention.ms=1000\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name            string
+		args            []string
+		wantNodeId      int32
+		wantLogDir      string
+		wantRetentionMs int64
+		wantErr         string
+	}{
+		{name: "defaults", args: nil, wantNodeId: 1, wantLogDir: "/tmp/kraft-combined-logs", wantRetentionMs: 7 * 24 * 60 * 60 * 1000},
+		{name: "properties file", args: []string{properties}, wantNodeId: 2, wantLogDir: "/data", wantRetentionMs: 1000},
+		{
+			name: "overrides after the file", args: []string{properties, "--override", "node.id=3", "--override", "log.dirs=/other"},
+			wantNodeId: 3, wantLogDir: "/other", wantRetentionMs: 1000,
+		},
+		{name: "overrides before the file", args: []string{"--override", "node.id=3", properties}, wantNodeId: 3, wantLogDir: "/data", wantRetentionMs: 1000},
+		{name: "overrides without a file", args: []string{"--override", "node.id=4"}, wantNodeId: 4, wantLogDir: "/tmp/kraft-combined-logs", wantRetentionMs: 7 * 24 * 60 * 60 * 1000},
+		{name: "later override wins", args: []string{"--override", "node.id=4", "--override", "node.id=5"}, wantNodeId: 5, wantLogDir: "/tmp/kraft-combined-logs", wantRetentionMs: 7 * 24 * 60 * 60 * 1000},
+		{
+			// the finer unit from the file still wins over a coarser override
+			name: "duration override", args: []string{properties, "--override", "log.retention.hours=1"},
+			wantNodeId: 2, wantLogDir: "/data", wantRetentionMs: 1000,
+		},
+		{
+			name: "finer duration override", args: []string{properties, "--override", "log.retention.ms=5"},
+			wantNodeId: 2, wantLogDir: "/data", wantRetentionMs: 5,
+		},
+		{name: "override without a value", args: []string{"--override", "node.id"}, wantErr: "not of the form key=value"},
+		{name: "invalid override", args: []string{"--override", "node.id=x"}, wantErr: "node.id"},
+		{name: "missing file", args: []string{filepath.Join(t.TempDir(), "missing.properties")}, wantErr: "no such file"},
+		{name: "extra argument", args: []string{properties, "other.properties"}, wantErr: "unexpected argument"},
+		{name: "controller listener only", args: []string{"--override", "controller.listener.names=PLAINTEXT"}, wantErr: "listeners"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadConfig(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.NodeId != tt.wantNodeId || cfg.LogDirs[0] != tt.wantLogDir || cfg.LogRetentionMs != tt.wantRetentionMs {
+				t.Fatalf("node %d, log dir %s, retention %dms; want node %d, log dir %s, retention %dms",
+					cfg.NodeId, cfg.LogDirs[0], cfg.LogRetentionMs, tt.wantNodeId, tt.wantLogDir, tt.wantRetentionMs)
+			}
+		})
+	}
+}
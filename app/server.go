@@ -1,27 +1,40 @@
 package main
 
 import (
+	"bufio"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
+	"os/signal"
+	"runtime/debug"
+	"syscall"
+	"time"
 
 	"github.com/codecrafters-io/kafka-starter-go/api"
 	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
 )
 
-func Read(conn net.Conn) (*api.Message, error) {
+// Read reads the next size-prefixed request from r, rejecting sizes above
+// maxRequestBytes before allocating for them.
+func Read(r *bufio.Reader, maxRequestBytes int32) (*api.Message, error) {
 	msg := &api.Message{}
 
 	messageSizeBytes := make([]byte, 4)
-	_, err := conn.Read(messageSizeBytes)
+	_, err := io.ReadFull(r, messageSizeBytes)
 	if err != nil {
 		return nil, err
 	}
 
 	messageSize := int32(binary.BigEndian.Uint32(messageSizeBytes))
+	if messageSize < 0 || messageSize > maxRequestBytes {
+		return nil, fmt.Errorf("%w: %d bytes (socket.request.max.bytes is %d)", errInvalidRequestSize, messageSize, maxRequestBytes)
+	}
 	bodyBytes := make([]byte, messageSize)
-	_, err = conn.Read(bodyBytes)
+	_, err = io.ReadFull(r, bodyBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -29,88 +42,216 @@ func Read(conn net.Conn) (*api.Message, error) {
 	req := &api.RawRequest{}
 	req = req.From(messageSizeBytes, bodyBytes)
 
-	return msg.FromRawRequest(req)
+	msg, err = msg.FromRawRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformedRequest, err)
+	}
+	return msg, nil
 }
 
+var (
+	// errMalformedRequest is returned by Read when a request cannot be decoded or
+	// has an unsupported version, which leaves no response it could be sent.
+	errMalformedRequest = errors.New("malformed request")
+	// errInvalidRequestSize is returned by Read when a request's size prefix is
+	// negative or above the limit, after which the stream cannot be resynchronized.
+	errInvalidRequestSize = errors.New("invalid request size")
+)
+
 func Send(conn net.Conn, response []byte) error {
 	_, err := conn.Write(response)
 	return err
 }
 
-func handleRequest(conn net.Conn) {
+// maxInFlightRequests bounds how many responses a connection may have pending
+// before reading further requests.
+const maxInFlightRequests = 16
+
+func (s *server) handleRequest(conn net.Conn) {
+	if !s.track(conn) {
+		conn.Close()
+		return
+	}
+	defer s.untrack(conn)
 	defer func(conn net.Conn) {
 		err := conn.Close()
-		if err != nil {
+		if err != nil && !errors.Is(err, net.ErrClosed) {
 			log.Println("Error closing connection: ", err.Error())
 		}
 	}(conn)
 
+	// Responses must be sent in request order, so each request reserves a slot
+	// in the queue that the writer drains one at a time.
+	responses := make(chan chan []byte, maxInFlightRequests)
+	written := make(chan struct{})
+	go func() {
+		s.writeResponses(conn, responses)
+		close(written)
+	}()
+
+	respondOn := func(response chan<- []byte, msg *api.Message) {
+		respBytes, err := s.respond(msg)
+		if err != nil {
+			// the client would wait forever for the missing response, so drop the connection
+			log.Println("Error encoding response: ", err.Error())
+			conn.Close()
+		}
+		response <- respBytes
+	}
+
+	reader := bufio.NewReader(conn)
 	for {
-		msg, err := Read(conn)
-		if err != nil && err.Error() == "EOF" {
+		msg, err := Read(reader, s.cfg.SocketRequestMaxBytes)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, errMalformedRequest) {
+			// Kafka closes the connection on requests it cannot parse
+			log.Println("Error decoding request: ", err.Error())
+			break
+		}
+		if err != nil && s.shuttingDown() {
 			break
 		}
 		if err != nil {
-			log.Println("Error reading data: ", err.Error())
-			os.Exit(1)
+			// a framing error only affects this connection, so drop it and keep serving others
+			log.Printf("Error reading data from %s: %s", conn.RemoteAddr(), err.Error())
+			break
 		}
 
-		respBytes := make([]byte, 8192)
-		enc := &encoder.BinaryEncoder{}
-		enc.Init(respBytes)
-		switch msg.Header.ApiKey {
-		case api.ApiVersions:
-			resp := api.PrepareAPIVersionsResponse(msg)
-			err = resp.Encode(enc)
-			if err != nil {
-				log.Println("Error preparing response: ", err.Error())
-				os.Exit(1)
-			}
-		case api.DescribeTopicPartitions:
-			resp := api.PrepareDescribeTopicPartitionsResponse(msg)
-			err = resp.Encode(enc)
-			if err != nil {
-				log.Println("Error encoding response: ", err.Error())
-				os.Exit(1)
-			}
-		case api.Fetch:
-			resp := api.PrepareFetchResponse(msg)
-			err = resp.Encode(enc)
-			if err != nil {
-				log.Println("Error encoding response: ", err.Error())
-				os.Exit(1)
-			}
+		s.inFlight.Add(1)
+		response := make(chan []byte, 1)
+		responses <- response
+		if msg.Header.ApiKey == api.Fetch {
+			// a fetch may wait for data, so let later requests be processed meanwhile
+			go respondOn(response, msg)
+			continue
+		}
+		respondOn(response, msg)
+	}
+	close(responses)
+	<-written
+}
+
+// writeResponses sends responses in order until one fails to be written, after
+// which the connection is closed and the remaining responses are discarded.
+func (s *server) writeResponses(conn net.Conn, responses <-chan chan []byte) {
+	failed := false
+	for response := range responses {
+		respBytes := <-response
+		if respBytes == nil || failed {
+			s.inFlight.Add(-1)
+			continue
 		}
-		respBytes = enc.ToKafkaResponse()
-		err = Send(conn, respBytes)
+		err := Send(conn, respBytes)
+		s.inFlight.Add(-1)
 		if err != nil {
-			log.Println("Error writing data: ", err.Error())
-			os.Exit(1)
+			log.Printf("Error writing data to %s: %s", conn.RemoteAddr(), err.Error())
+			// closing the connection also stops the reader
+			conn.Close()
+			failed = true
 		}
 	}
 }
 
+// respond handles a single request and returns the encoded response, or nil
+// when the client does not expect one. A handler that panics or a response that
+// cannot be encoded is answered with UNKNOWN_SERVER_ERROR.
+func (s *server) respond(msg *api.Message) (respBytes []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Error handling request (api key %d, version %d): %v\n%s", msg.Header.ApiKey, msg.Header.ApiVersion, r, debug.Stack())
+			respBytes, err = respondWithError(msg, api.ErrorUnknownServer)
+		}
+	}()
+
+	resp := api.HandleRequest(s.cfg, msg)
+	if resp == nil {
+		return nil, nil
+	}
+
+	enc := &encoder.BinaryEncoder{}
+	enc.InitResponse(nil)
+	err = resp.Encode(enc)
+	if err != nil {
+		log.Println("Error encoding response: ", err.Error())
+		return respondWithError(msg, api.ErrorUnknownServer)
+	}
+	return enc.ToKafkaResponse(), nil
+}
+
+func respondWithError(msg *api.Message, errorCode api.ErrorCode) ([]byte, error) {
+	resp := api.HandleError(msg, errorCode)
+	if resp == nil {
+		return nil, nil
+	}
+	enc := &encoder.BinaryEncoder{}
+	enc.InitResponse(nil)
+	if err := resp.Encode(enc); err != nil {
+		return nil, err
+	}
+	return enc.ToKafkaResponse(), nil
+}
+
 func main() {
+	cfg, err := loadConfig(os.Args[1:])
+	if err != nil {
+		log.Println("Error loading configuration: ", err.Error())
+		os.Exit(1)
+	}
 	log.Println("Logs from your program will appear here!")
 
-	l, err := net.Listen("tcp", "0.0.0.0:9092")
-	if err != nil {
-		log.Println("Failed to bind to port 9092")
+	if err = api.RecoverLogs(cfg); err != nil {
+		log.Println("Error recovering logs: ", err.Error())
 		os.Exit(1)
 	}
-	defer func(l net.Listener) {
-		err := l.Close()
+
+	var listeners []net.Listener
+	for _, endpoint := range cfg.BrokerListeners() {
+		l, err := net.Listen("tcp", endpoint.Address())
 		if err != nil {
-			log.Println("Error closing listener: ", err.Error())
+			log.Printf("Failed to bind to %s: %s", endpoint, err.Error())
+			os.Exit(1)
 		}
-	}(l)
+		log.Printf("Listening on %s", endpoint)
+		listeners = append(listeners, l)
+	}
+	s := newServer(cfg, listeners)
+	s.runInBackground(func(stop <-chan struct{}) { api.RunLogRetention(cfg, stop) })
+	s.runInBackground(func(stop <-chan struct{}) { api.RunLogCleaner(cfg, stop) })
+	for _, l := range listeners {
+		go s.serve(l)
+	}
 
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	log.Println("Received signal: ", sig.String())
+	s.shutdown(time.Duration(cfg.ShutdownTimeoutMs) * time.Millisecond)
+}
+
+// serve accepts connections until the listener is closed.
+func (s *server) serve(listener net.Listener) {
+	var acceptDelay time.Duration
 	for {
-		conn, err := l.Accept()
+		conn, err := listener.Accept()
 		if err != nil {
-			log.Println("Error accepting connection: ", err.Error())
-			os.Exit(1)
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			// errors such as running out of file descriptors are usually transient,
+			// so back off and try again rather than giving up on every client
+			acceptDelay = min(max(2*acceptDelay, minAcceptDelay), maxAcceptDelay)
+			log.Printf("Error accepting connection: %s; retrying in %v", err.Error(), acceptDelay)
+			time.Sleep(acceptDelay)
+			continue
 		}
-		go handleRequest(conn)
+		acceptDelay = 0
+		go s.handleRequest(conn)
 	}
 }
+
+const (
+	minAcceptDelay = 5 * time.Millisecond
+	maxAcceptDelay = time.Second
+)
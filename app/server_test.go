@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/api"
+	"github.com/codecrafters-io/kafka-starter-go/config"
+	kafkalog "github.com/codecrafters-io/kafka-starter-go/log"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/messages"
+	"github.com/codecrafters-io/kafka-starter-go/record"
+	"github.com/google/uuid"
+)
+
+type requestBody interface {
+	Encode(enc *encoder.BinaryEncoder, version int16) error
+}
+
+// frame returns a request as a client sends it, size prefix included.
+func frame(t *testing.T, apiKey api.ApiKey, version int16, correlationId int32, req requestBody) []byte {
+	t.Helper()
+	enc := &encoder.BinaryEncoder{}
+	enc.InitResponse(nil) // reserves the size prefix, as for a response
+	enc.PutInt16(apiKey)
+	enc.PutInt16(version)
+	enc.PutInt32(correlationId)
+	enc.PutNullableString(nil)
+	if api.RequestHeaderVersion(apiKey, version) >= 2 {
+		enc.PutEmptyTaggedFieldArray()
+	}
+	if err := req.Encode(enc, version); err != nil {
+		t.Fatal(err)
+	}
+	return enc.ToKafkaResponse()
+}
+
+func TestRead(t *testing.T) {
+	valid := frame(t, api.ApiVersions, 4, 1, &messages.ApiVersionsRequest{})
+	sized := func(size int32, payload []byte) []byte {
+		return append(binary.BigEndian.AppendUint32(nil, uint32(size)), payload...)
+	}
+	tests := []struct {
+		name    string
+		raw     []byte
+		wantErr error
+	}{
+		{name: "valid", raw: valid},
+		{name: "at the size limit", raw: sized(100, append(valid[4:], make([]byte, 100-len(valid[4:]))...))},
+		{name: "no request", raw: nil, wantErr: io.EOF},
+		{name: "short size", raw: valid[:2], wantErr: io.ErrUnexpectedEOF},
+		{name: "short payload", raw: valid[:len(valid)-1], wantErr: io.ErrUnexpectedEOF},
+		{name: "over the size limit", raw: sized(101, make([]byte, 101)), wantErr: errInvalidRequestSize},
+		{name: "negative size", raw: sized(-1, nil), wantErr: errInvalidRequestSize},
+		{name: "header cut off", raw: sized(3, []byte{0, 18, 0}), wantErr: errMalformedRequest},
+		{name: "unsupported version", raw: frame(t, api.Metadata, 99, 1, &messages.MetadataRequest{}), wantErr: errMalformedRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Read(bufio.NewReader(bytes.NewReader(tt.raw)), 100)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && msg.Header.CorrelationId != 1 {
+				t.Fatalf("message %+v", msg)
+			}
+		})
+	}
+}
+
+// TestReadShortReads checks that a request arriving a byte at a time is read whole.
+func TestReadShortReads(t *testing.T) {
+	client, conn := net.Pipe()
+	defer client.Close()
+	defer conn.Close()
+	raw := frame(t, api.ApiVersions, 4, 1, &messages.ApiVersionsRequest{})
+	go func() {
+		for i := range raw {
+			client.Write(raw[i : i+1])
+		}
+	}()
+	msg, err := Read(bufio.NewReaderSize(conn, 16), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Header.ApiKey != api.ApiVersions || msg.Header.CorrelationId != 1 {
+		t.Fatalf("message %+v", msg)
+	}
+}
+
+// testConfig returns a config with a log directory of its own, whose cluster
+// metadata log holds a single topic with one partition.
+func testConfig(t *testing.T, topic string, topicID uuid.UUID) *config.Config {
+	t.Helper()
+	cfg := config.Default()
+	cfg.LogDirs = []string{t.TempDir()}
+	cfg.SocketRequestMaxBytes = 1024
+
+	value := func(recordType int8, put func(enc *encoder.BinaryEncoder)) []byte {
+		enc := &encoder.BinaryEncoder{}
+		enc.Init(nil)
+		enc.PutInt8(1) // frame version
+		enc.PutInt8(recordType)
+		enc.PutInt8(0) // version
+		put(enc)
+		return enc.ToBytes()
+	}
+	values := [][]byte{
+		value(2, func(enc *encoder.BinaryEncoder) {
+			enc.PutCompactString(topic)
+			enc.PutRawBytes(topicID[:])
+			enc.PutEmptyTaggedFieldArray()
+		}),
+		value(3, func(enc *encoder.BinaryEncoder) {
+			enc.PutInt32(0) // partition
+			enc.PutRawBytes(topicID[:])
+			enc.PutCompactInt32Array([]int32{cfg.NodeId}) // replicas
+			enc.PutCompactInt32Array([]int32{cfg.NodeId}) // in-sync replicas
+			enc.PutCompactInt32Array(nil)                 // removing replicas
+			enc.PutCompactInt32Array(nil)                 // adding replicas
+			enc.PutInt32(cfg.NodeId)                      // leader
+			enc.PutInt32(0)                               // leader epoch
+			enc.PutInt32(0)                               // partition epoch
+			enc.PutCompactArrayLen(0)                     // directories
+			enc.PutEmptyTaggedFieldArray()
+		}),
+	}
+	batch := record.RecordBatch{Magic: 2, LastOffsetDelta: int32(len(values) - 1), ProducerId: -1, ProducerEpoch: -1, BaseSequence: -1}
+	for i, value := range values {
+		r := record.Record{OffsetDelta: int64(i), KeyLength: -1, ValueLength: int64(len(value)), Value: value}
+		r.Length = r.GetEncodedLength()
+		batch.Records = append(batch.Records, r)
+	}
+	enc := &encoder.BinaryEncoder{}
+	enc.Init(nil)
+	if err := batch.Encode(enc); err != nil {
+		t.Fatal(err)
+	}
+	l, err := kafkalog.Open(filepath.Join(cfg.ClusterMetadataLogDir(), "__cluster_metadata-0"), kafkalog.Config{
+		SegmentBytes:       int64(cfg.LogSegmentBytes),
+		SegmentMs:          cfg.LogRollMs,
+		IndexIntervalBytes: int(cfg.LogIndexIntervalBytes),
+		SegmentIndexBytes:  int(cfg.LogIndexSizeMaxBytes),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if _, err = l.Append([][]byte{enc.Bytes()}, false); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// connect starts a server and returns a connection to it.
+func connect(t *testing.T, cfg *config.Config) net.Conn {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newServer(cfg, []net.Listener{l})
+	go s.serve(l)
+	t.Cleanup(func() { l.Close() })
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	return conn
+}
+
+// readResponse reads a response, returning its correlation id and the rest of it.
+func readResponse(t *testing.T, conn net.Conn) (int32, []byte) {
+	t.Helper()
+	size := make([]byte, 4)
+	if _, err := io.ReadFull(conn, size); err != nil {
+		t.Fatal(err)
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(size))
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		t.Fatal(err)
+	}
+	dec := &decoder.BinaryDecoder{}
+	dec.Init(payload)
+	return dec.GetInt32(), payload[4:]
+}
+
+// TestResponseOrder parks a Fetch waiting for data, then sends more requests
+// than may be in flight, which must all be answered after it, in order.
+func TestResponseOrder(t *testing.T) {
+	topicID := uuid.New()
+	conn := connect(t, testConfig(t, "foo", topicID))
+
+	const maxWait = 300 * time.Millisecond
+	fetch := &messages.FetchRequest{}
+	fetch.SetDefaults()
+	fetch.MaxWaitMs = int32(maxWait.Milliseconds())
+	fetch.MinBytes = 1
+	fetch.Topics = []messages.FetchRequestFetchTopic{{
+		TopicId:    topicID,
+		Partitions: []messages.FetchRequestFetchPartition{{Partition: 0, PartitionMaxBytes: 1 << 20}},
+	}}
+	start := time.Now()
+	requests := frame(t, api.Fetch, 16, 0, fetch)
+	const count = 2 * maxInFlightRequests
+	for i := int32(1); i <= count; i++ {
+		requests = append(requests, frame(t, api.ApiVersions, 4, i, &messages.ApiVersionsRequest{})...)
+	}
+	go conn.Write(requests)
+
+	for want := int32(0); want <= count; want++ {
+		correlationId, _ := readResponse(t, conn)
+		if correlationId != want {
+			t.Fatalf("response to request %d, want %d", correlationId, want)
+		}
+		if want == 0 && time.Since(start) < maxWait {
+			t.Fatalf("fetch answered after %v, before MaxWaitMs", time.Since(start))
+		}
+	}
+}
+
+func TestUnknownApiKeepsConnection(t *testing.T) {
+	conn := connect(t, testConfig(t, "foo", uuid.New()))
+	unknown := &encoder.BinaryEncoder{}
+	unknown.InitResponse(nil)
+	unknown.PutInt16(1000) // api key
+	unknown.PutInt16(0)
+	unknown.PutInt32(1)
+	unknown.PutNullableString(nil)
+	requests := append(unknown.ToKafkaResponse(), frame(t, api.ApiVersions, 4, 2, &messages.ApiVersionsRequest{})...)
+	if _, err := conn.Write(requests); err != nil {
+		t.Fatal(err)
+	}
+
+	correlationId, body := readResponse(t, conn)
+	if correlationId != 1 || !bytes.Equal(body, []byte{0, byte(api.ErrorUnsupportedVersion)}) {
+		t.Fatalf("response %d %x to the unknown api, want error %d", correlationId, body, api.ErrorUnsupportedVersion)
+	}
+	if correlationId, _ = readResponse(t, conn); correlationId != 2 {
+		t.Fatalf("response to request %d, want 2", correlationId)
+	}
+}
+
+func TestClosesConnection(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  []byte
+	}{
+		{name: "request over socket.request.max.bytes", raw: binary.BigEndian.AppendUint32(nil, 1025)},
+		{name: "unsupported version", raw: frame(t, api.Metadata, 99, 1, &messages.MetadataRequest{})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := connect(t, testConfig(t, "foo", uuid.New()))
+			if _, err := conn.Write(tt.raw); err != nil {
+				t.Fatal(err)
+			}
+			if n, err := conn.Read(make([]byte, 1)); err != io.EOF {
+				t.Fatalf("read %d bytes, error %v; want the connection closed", n, err)
+			}
+		})
+	}
+}
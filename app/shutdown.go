@@ -0,0 +1,121 @@
+package main
+
+import (
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/api"
+	"github.com/codecrafters-io/kafka-starter-go/config"
+)
+
+// server serves clients with the given configuration. It tracks the open
+// connections so that a shutdown can stop reading new requests from them and
+// wait for the responses already being prepared.
+type server struct {
+	cfg       *config.Config
+	listeners []net.Listener
+
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	closing  bool
+	active   sync.WaitGroup
+	inFlight atomic.Int64 // requests read whose response is not yet written
+
+	stop       chan struct{} // closed on shutdown to stop background tasks
+	background sync.WaitGroup
+}
+
+func newServer(cfg *config.Config, listeners []net.Listener) *server {
+	return &server{cfg: cfg, listeners: listeners, conns: make(map[net.Conn]struct{}), stop: make(chan struct{})}
+}
+
+// runInBackground runs a task that returns once its stop channel is closed,
+// which happens at the start of a shutdown.
+func (s *server) runInBackground(task func(stop <-chan struct{})) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		task(s.stop)
+	}()
+}
+
+// track registers a new connection, returning false if the server is shutting down.
+func (s *server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	s.active.Add(1)
+	return true
+}
+
+func (s *server) untrack(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, conn)
+	s.active.Done()
+}
+
+func (s *server) shuttingDown() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closing
+}
+
+// shutdown stops accepting connections, stops reading requests from the open
+// ones and waits up to timeout for their in-flight requests to be answered
+// before closing them. Once background tasks have stopped, partition logs are
+// synced and closed, which waits for appends still running when the timeout
+// hit and fails later ones, and only then are the log directories marked as
+// cleanly shut down.
+func (s *server) shutdown(timeout time.Duration) {
+	start := time.Now()
+	s.mu.Lock()
+	s.closing = true
+	close(s.stop)
+	for _, listener := range s.listeners {
+		if err := listener.Close(); err != nil {
+			log.Println("Error closing listener: ", err.Error())
+		}
+	}
+	connections := len(s.conns)
+	for conn := range s.conns {
+		// wakes the reader, which then waits for the pending responses to be written
+		conn.SetReadDeadline(start)
+	}
+	s.mu.Unlock()
+	inFlight := s.inFlight.Load()
+	log.Printf("Shutting down: waiting up to %v for %d in-flight requests on %d connections", timeout, inFlight, connections)
+
+	drained := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(timeout):
+		s.mu.Lock()
+		log.Printf("Shutdown timed out with %d requests in flight; closing %d connections", s.inFlight.Load(), len(s.conns))
+		for conn := range s.conns {
+			conn.Close()
+		}
+		s.mu.Unlock()
+	}
+	abandoned := s.inFlight.Load()
+	s.background.Wait()
+
+	closed, err := api.ClosePartitionLogs()
+	if err != nil {
+		log.Println("Error closing partition logs: ", err.Error())
+	} else if err = api.MarkCleanShutdown(s.cfg); err != nil {
+		log.Println("Error marking clean shutdown: ", err.Error())
+	}
+	log.Printf("Shut down in %v: %d of %d in-flight requests completed, %d partition logs closed",
+		time.Since(start).Round(time.Millisecond), inFlight-abandoned, inFlight, closed)
+}
@@ -0,0 +1,365 @@
+// Package config holds the broker configuration, read from a Kafka-style
+// server.properties file with command-line overrides.
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Endpoint is a listener as written in listeners and advertised.listeners,
+// such as PLAINTEXT://localhost:9092.
+type Endpoint struct {
+	Name string
+	Host string
+	Port int32
+}
+
+// Address returns the address to listen on; an empty host listens on all interfaces.
+func (e Endpoint) Address() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(int(e.Port)))
+}
+
+func (e Endpoint) String() string {
+	return e.Name + "://" + e.Address()
+}
+
+type Config struct {
+	NodeId                  int32
+	Listeners               []Endpoint
+	AdvertisedListeners     []Endpoint
+	ControllerListenerNames []string
+	LogDirs                 []string
+	MetadataLogDir          string
+	SocketRequestMaxBytes   int32
+	ShutdownTimeoutMs       int64
+	// NumPartitions is the partition count of topics created without one. The
+	// controller creates topics, so the broker only keeps it for server.properties.
+	NumPartitions               int32
+	LogSegmentBytes             int32
+	LogRollMs                   int64
+	LogIndexIntervalBytes       int32
+	LogIndexSizeMaxBytes        int32
+	LogRetentionMs              int64
+	LogRetentionBytes           int64
+	LogRetentionCheckIntervalMs int64
+	LogCleanupPolicy            []string
+	LogCleanerDeleteRetentionMs int64
+	LogCleanerBackoffMs         int64
+
+	advertisedListenersSet bool
+	metadataLogDirSet      bool
+
+	// durations set in several units, resolved by resolveDurations
+	logRollMs           *int64
+	logRollHours        *int64
+	logRetentionMs      *int64
+	logRetentionMinutes *int64
+	logRetentionHours   *int64
+}
+
+// Default returns the configuration used for keys missing from server.properties.
+// The log directory matches the one the codecrafters tests prepare.
+func Default() *Config {
+	return &Config{
+		NodeId:                      1,
+		Listeners:                   []Endpoint{{Name: "PLAINTEXT", Port: 9092}},
+		LogDirs:                     []string{"/tmp/kraft-combined-logs"},
+		SocketRequestMaxBytes:       104857600,
+		ShutdownTimeoutMs:           30000,
+		NumPartitions:               1,
+		LogSegmentBytes:             1073741824,
+		LogRollMs:                   7 * 24 * 60 * 60 * 1000,
+		LogIndexIntervalBytes:       4096,
+		LogIndexSizeMaxBytes:        10485760,
+		LogRetentionMs:              7 * 24 * 60 * 60 * 1000,
+		LogRetentionBytes:           -1,
+		LogRetentionCheckIntervalMs: 5 * 60 * 1000,
+		LogCleanupPolicy:            []string{CleanupPolicyDelete},
+		LogCleanerDeleteRetentionMs: 24 * 60 * 60 * 1000,
+		LogCleanerBackoffMs:         15 * 1000,
+	}
+}
+
+// Load reads a server.properties file on top of the defaults.
+func Load(path string) (*Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	c := Default()
+	properties, err := ParseProperties(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, property := range properties {
+		if err := c.Set(property[0], property[1]); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return c, nil
+}
+
+// ParseProperties reads key-value pairs in the Java properties format used by
+// server.properties, in file order.
+func ParseProperties(r io.Reader) ([][2]string, error) {
+	var properties [][2]string
+	scanner := bufio.NewScanner(r)
+	var line string
+	for scanner.Scan() {
+		text := strings.TrimLeft(scanner.Text(), " \t\f")
+		if line == "" && (text == "" || text[0] == '#' || text[0] == '!') {
+			continue
+		}
+		// a trailing backslash continues the entry on the next line
+		if strings.HasSuffix(text, `\`) && !strings.HasSuffix(text, `\\`) {
+			line += strings.TrimSuffix(text, `\`)
+			continue
+		}
+		line += text
+		key, value := splitProperty(line)
+		properties = append(properties, [2]string{key, value})
+		line = ""
+	}
+	if line != "" {
+		key, value := splitProperty(line)
+		properties = append(properties, [2]string{key, value})
+	}
+	return properties, scanner.Err()
+}
+
+// splitProperty splits a line at the first '=', ':' or whitespace.
+func splitProperty(line string) (string, string) {
+	i := strings.IndexAny(line, "=: \t\f")
+	if i < 0 {
+		return line, ""
+	}
+	key := line[:i]
+	value := strings.TrimLeft(line[i:], " \t\f")
+	if value != "" && (value[0] == '=' || value[0] == ':') {
+		value = strings.TrimLeft(value[1:], " \t\f")
+	}
+	return key, strings.TrimRight(value, " \t\f")
+}
+
+// Set applies a single property. Unknown properties are ignored, since a
+// server.properties written for Kafka holds many this broker has no use for.
+func (c *Config) Set(key, value string) error {
+	var err error
+	switch key {
+	case "node.id", "broker.id":
+		c.NodeId, err = parseInt32(key, value)
+	case "listeners":
+		c.Listeners, err = parseEndpoints(key, value)
+	case "advertised.listeners":
+		c.AdvertisedListeners, err = parseEndpoints(key, value)
+		c.advertisedListenersSet = true
+	case "controller.listener.names":
+		c.ControllerListenerNames = parseList(value)
+	case "log.dirs", "log.dir":
+		c.LogDirs = parseList(value)
+		if len(c.LogDirs) == 0 {
+			err = fmt.Errorf("%s must name at least one directory", key)
+		}
+	case "metadata.log.dir":
+		c.MetadataLogDir = value
+		c.metadataLogDirSet = value != ""
+	case "socket.request.max.bytes":
+		c.SocketRequestMaxBytes, err = parseInt32(key, value)
+		if err == nil && c.SocketRequestMaxBytes <= 0 {
+			err = fmt.Errorf("%s must be positive", key)
+		}
+	case "shutdown.timeout.ms":
+		c.ShutdownTimeoutMs, err = parseInt64(key, value)
+	case "num.partitions":
+		c.NumPartitions, err = parseInt32(key, value)
+		if err == nil && c.NumPartitions <= 0 {
+			err = fmt.Errorf("%s must be positive", key)
+		}
+	case "log.segment.bytes":
+		c.LogSegmentBytes, err = parseInt32(key, value)
+		if err == nil && c.LogSegmentBytes < 14 {
+			err = fmt.Errorf("%s must be at least 14", key)
+		}
+	case "log.roll.ms":
+		c.logRollMs, err = parseDuration(key, value, 1)
+	case "log.roll.hours":
+		c.logRollHours, err = parseDuration(key, value, 60*60*1000)
+	case "log.index.interval.bytes":
+		c.LogIndexIntervalBytes, err = parseInt32(key, value)
+	case "log.index.size.max.bytes":
+		c.LogIndexSizeMaxBytes, err = parseInt32(key, value)
+		if err == nil && c.LogIndexSizeMaxBytes < 12 {
+			err = fmt.Errorf("%s must be at least 12", key)
+		}
+	case "log.retention.ms":
+		c.logRetentionMs, err = parseDuration(key, value, 1)
+	case "log.retention.minutes":
+		c.logRetentionMinutes, err = parseDuration(key, value, 60*1000)
+	case "log.retention.hours":
+		c.logRetentionHours, err = parseDuration(key, value, 60*60*1000)
+	case "log.retention.bytes":
+		c.LogRetentionBytes, err = parseInt64(key, value)
+	case "log.retention.check.interval.ms":
+		c.LogRetentionCheckIntervalMs, err = parseInt64(key, value)
+		if err == nil && c.LogRetentionCheckIntervalMs <= 0 {
+			err = fmt.Errorf("%s must be positive", key)
+		}
+	case "log.cleanup.policy":
+		c.LogCleanupPolicy, err = parseCleanupPolicy(key, value)
+	case "log.cleaner.delete.retention.ms":
+		c.LogCleanerDeleteRetentionMs, err = parseInt64(key, value)
+	case "log.cleaner.backoff.ms":
+		c.LogCleanerBackoffMs, err = parseInt64(key, value)
+		if err == nil && c.LogCleanerBackoffMs <= 0 {
+			err = fmt.Errorf("%s must be positive", key)
+		}
+	}
+	c.resolveDurations()
+	return err
+}
+
+// resolveDurations applies Kafka's precedence between the keys setting one
+// duration in different units: the finest unit set wins, whichever order the
+// keys were set in.
+func (c *Config) resolveDurations() {
+	if ms := firstSet(c.logRollMs, c.logRollHours); ms != nil {
+		c.LogRollMs = *ms
+	}
+	if ms := firstSet(c.logRetentionMs, c.logRetentionMinutes, c.logRetentionHours); ms != nil {
+		c.LogRetentionMs = *ms
+	}
+}
+
+func firstSet(values ...*int64) *int64 {
+	for _, value := range values {
+		if value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+// BrokerListeners returns the listeners serving clients, leaving out those
+// reserved for the KRaft controller.
+func (c *Config) BrokerListeners() []Endpoint {
+	var endpoints []Endpoint
+	for _, endpoint := range c.Listeners {
+		if !c.isControllerListener(endpoint.Name) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// AdvertisedBrokerListeners returns the endpoints clients are told to connect
+// to, which default to the broker listeners with localhost for empty hosts.
+func (c *Config) AdvertisedBrokerListeners() []Endpoint {
+	endpoints := c.AdvertisedListeners
+	if !c.advertisedListenersSet {
+		endpoints = c.BrokerListeners()
+	}
+	advertised := make([]Endpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if c.isControllerListener(endpoint.Name) {
+			continue
+		}
+		if endpoint.Host == "" {
+			endpoint.Host = "localhost"
+		}
+		advertised = append(advertised, endpoint)
+	}
+	return advertised
+}
+
+// ClusterMetadataLogDir returns the directory holding the cluster metadata log
+// and meta.properties, which defaults to the first log directory.
+func (c *Config) ClusterMetadataLogDir() string {
+	if c.metadataLogDirSet {
+		return c.MetadataLogDir
+	}
+	return c.LogDirs[0]
+}
+
+// Validate reports configurations the broker cannot start with.
+func (c *Config) Validate() error {
+	if len(c.BrokerListeners()) == 0 {
+		return fmt.Errorf("listeners must include a listener that is not in controller.listener.names")
+	}
+	if len(c.AdvertisedBrokerListeners()) == 0 {
+		return fmt.Errorf("advertised.listeners must include a listener that is not in controller.listener.names")
+	}
+	return nil
+}
+
+func (c *Config) isControllerListener(name string) bool {
+	for _, controllerName := range c.ControllerListenerNames {
+		if name == controllerName {
+			return true
+		}
+	}
+	return false
+}
+
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseEndpoints(key, value string) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	for _, item := range parseList(value) {
+		name, address, found := strings.Cut(item, "://")
+		if !found {
+			return nil, fmt.Errorf("%s: %q is not of the form NAME://host:port", key, item)
+		}
+		host, portString, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q: %w", key, item, err)
+		}
+		port, err := strconv.ParseUint(portString, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q has an invalid port", key, item)
+		}
+		endpoints = append(endpoints, Endpoint{Name: name, Host: host, Port: int32(port)})
+	}
+	return endpoints, nil
+}
+
+func parseInt32(key, value string) (int32, error) {
+	n, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %q is not a 32-bit integer", key, value)
+	}
+	return int32(n), nil
+}
+
+// parseDuration parses a duration given in units of unitMs milliseconds.
+func parseDuration(key, value string, unitMs int64) (*int64, error) {
+	n, err := parseInt64(key, value)
+	if err != nil {
+		return nil, err
+	}
+	ms := n * unitMs
+	return &ms, nil
+}
+
+func parseInt64(key, value string) (int64, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %q is not an integer", key, value)
+	}
+	return n, nil
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseProperties(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want [][2]string
+	}{
+		{name: "equals", in: "node.id=2\n", want: [][2]string{{"node.id", "2"}}},
+		{name: "colon", in: "node.id:2", want: [][2]string{{"node.id", "2"}}},
+		{name: "whitespace separator", in: "node.id 2", want: [][2]string{{"node.id", "2"}}},
+		{name: "whitespace around", in: "  node.id =  2 \t\n", want: [][2]string{{"node.id", "2"}}},
+		{name: "empty value", in: "metadata.log.dir=", want: [][2]string{{"metadata.log.dir", ""}}},
+		{name: "key only", in: "node.id", want: [][2]string{{"node.id", ""}}},
+		{name: "value with separators", in: "listeners=PLAINTEXT://:9092", want: [][2]string{{"listeners", "PLAINTEXT://:9092"}}},
+		{
+			name: "comments and blank lines",
+			in:   "# a comment\n! another\n\n   # indented\nnode.id=2\n",
+			want: [][2]string{{"node.id", "2"}},
+		},
+		{
+			name: "continuation lines",
+			in:   "log.dirs=/a,\\\n    /b,\\\n    /c\nnode.id=2",
+			want: [][2]string{{"log.dirs", "/a,/b,/c"}, {"node.id", "2"}},
+		},
+		{
+			// a comment character on a continuation line is part of the value
+			name: "comment character in a continuation",
+			in:   "log.dirs=/a,\\\n#b\n",
+			want: [][2]string{{"log.dirs", "/a,#b"}},
+		},
+		{name: "continuation at the end of the file", in: "log.dirs=/a,\\", want: [][2]string{{"log.dirs", "/a,"}}},
+		{name: "escaped backslash", in: `log.dir=C:\\` + "\nnode.id=2", want: [][2]string{{"log.dir", `C:\\`}, {"node.id", "2"}}},
+		{name: "file order", in: "node.id=2\nnode.id=3", want: [][2]string{{"node.id", "2"}, {"node.id", "3"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProperties(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parsed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationPrecedence(t *testing.T) {
+	const hour = 60 * 60 * 1000
+	tests := []struct {
+		name          string
+		properties    [][2]string
+		wantRollMs    int64
+		wantRetention int64
+	}{
+		{name: "defaults", wantRollMs: 7 * 24 * hour, wantRetention: 7 * 24 * hour},
+		{name: "hours", properties: [][2]string{{"log.roll.hours", "2"}, {"log.retention.hours", "3"}}, wantRollMs: 2 * hour, wantRetention: 3 * hour},
+		{name: "minutes", properties: [][2]string{{"log.retention.minutes", "5"}}, wantRollMs: 7 * 24 * hour, wantRetention: 5 * 60 * 1000},
+		{
+			name:       "ms over hours",
+			properties: [][2]string{{"log.roll.ms", "100"}, {"log.roll.hours", "2"}, {"log.retention.ms", "200"}, {"log.retention.hours", "3"}},
+			wantRollMs: 100, wantRetention: 200,
+		},
+		{
+			// an override of a coarser unit does not beat a finer one from the file
+			name:       "ms over hours set later",
+			properties: [][2]string{{"log.retention.hours", "3"}, {"log.retention.ms", "200"}, {"log.retention.hours", "4"}},
+			wantRollMs: 7 * 24 * hour, wantRetention: 200,
+		},
+		{
+			name:       "ms over minutes over hours",
+			properties: [][2]string{{"log.retention.hours", "3"}, {"log.retention.minutes", "5"}},
+			wantRollMs: 7 * 24 * hour, wantRetention: 5 * 60 * 1000,
+		},
+		{
+			name:       "all three",
+			properties: [][2]string{{"log.retention.minutes", "5"}, {"log.retention.ms", "200"}, {"log.retention.hours", "3"}},
+			wantRollMs: 7 * 24 * hour, wantRetention: 200,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			for _, property := range tt.properties {
+				if err := c.Set(property[0], property[1]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if c.LogRollMs != tt.wantRollMs || c.LogRetentionMs != tt.wantRetention {
+				t.Fatalf("roll %dms, retention %dms; want %dms and %dms", c.LogRollMs, c.LogRetentionMs, tt.wantRollMs, tt.wantRetention)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		key, value string
+		wantErr    string // empty when the value is valid
+		check      func(c *Config) bool
+	}{
+		{key: "node.id", value: "3", check: func(c *Config) bool { return c.NodeId == 3 }},
+		{key: "broker.id", value: "4", check: func(c *Config) bool { return c.NodeId == 4 }},
+		{key: "node.id", value: "x", wantErr: "not a 32-bit integer"},
+		{key: "node.id", value: "4294967296", wantErr: "not a 32-bit integer"},
+		{key: "num.partitions", value: "3", check: func(c *Config) bool { return c.NumPartitions == 3 }},
+		{key: "num.partitions", value: "0", wantErr: "must be positive"},
+		{key: "num.partitions", value: "-1", wantErr: "must be positive"},
+		{
+			key: "listeners", value: "PLAINTEXT://:9092, CONTROLLER://localhost:9093",
+			check: func(c *Config) bool {
+				return reflect.DeepEqual(c.Listeners, []Endpoint{{Name: "PLAINTEXT", Port: 9092}, {Name: "CONTROLLER", Host: "localhost", Port: 9093}})
+			},
+		},
+		{key: "listeners", value: "localhost:9092", wantErr: "not of the form"},
+		{key: "listeners", value: "PLAINTEXT://localhost", wantErr: "missing port"},
+		{key: "listeners", value: "PLAINTEXT://:70000", wantErr: "invalid port"},
+		{key: "log.dirs", value: "/a, /b", check: func(c *Config) bool { return reflect.DeepEqual(c.LogDirs, []string{"/a", "/b"}) }},
+		{key: "log.dirs", value: " , ", wantErr: "at least one directory"},
+		{key: "socket.request.max.bytes", value: "0", wantErr: "must be positive"},
+		{key: "log.segment.bytes", value: "13", wantErr: "at least 14"},
+		{key: "log.index.size.max.bytes", value: "11", wantErr: "at least 12"},
+		{key: "log.retention.check.interval.ms", value: "0", wantErr: "must be positive"},
+		{key: "log.cleaner.backoff.ms", value: "0", wantErr: "must be positive"},
+		{key: "log.retention.hours", value: "1h", wantErr: "not an integer"},
+		{key: "log.cleanup.policy", value: "compact,delete", check: func(c *Config) bool { return len(c.LogCleanupPolicy) == 2 }},
+		{key: "log.cleanup.policy", value: "archive", wantErr: "archive"},
+		{key: "unknown.key", value: "x", check: func(c *Config) bool { return reflect.DeepEqual(c, Default()) }},
+	}
+	for _, tt := range tests {
+		c := Default()
+		err := c.Set(tt.key, tt.value)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), tt.key) {
+				t.Fatalf("%s=%s: error %v, want one naming the key and containing %q", tt.key, tt.value, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s=%s: %v", tt.key, tt.value, err)
+		}
+		if !tt.check(c) {
+			t.Fatalf("%s=%s: config %+v", tt.key, tt.value, c)
+		}
+	}
+}
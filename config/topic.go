@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Cleanup policies, which decide how a topic's log is kept from growing.
+const (
+	// CleanupPolicyDelete deletes old segments according to retention.ms and retention.bytes.
+	CleanupPolicyDelete = "delete"
+	// CleanupPolicyCompact keeps only the latest record of each key.
+	CleanupPolicyCompact = "compact"
+)
+
+// TopicConfig holds the log settings of a topic: the broker defaults, unless
+// the topic overrides them with configs of its own.
+type TopicConfig struct {
+	CleanupPolicy []string
+	// RetentionMs is how long a closed segment is kept after its newest
+	// record, and RetentionBytes how large the log may grow before its oldest
+	// closed segments are deleted. -1 retains without limit.
+	RetentionMs    int64
+	RetentionBytes int64
+	// DeleteRetentionMs is how long compaction keeps tombstones, so that
+	// consumers get to see the deletion.
+	DeleteRetentionMs int64
+	// SegmentBytes and SegmentMs are how large and how old the active segment
+	// may grow before a new one is rolled.
+	SegmentBytes int32
+	SegmentMs    int64
+}
+
+// TopicConfig returns the settings of a topic with the given topic-level
+// configs, such as retention.ms. Unknown configs are ignored.
+func (c *Config) TopicConfig(configs map[string]string) (TopicConfig, error) {
+	t := TopicConfig{
+		CleanupPolicy:     c.LogCleanupPolicy,
+		RetentionMs:       c.LogRetentionMs,
+		RetentionBytes:    c.LogRetentionBytes,
+		DeleteRetentionMs: c.LogCleanerDeleteRetentionMs,
+		SegmentBytes:      c.LogSegmentBytes,
+		SegmentMs:         c.LogRollMs,
+	}
+	for key, value := range configs {
+		var err error
+		switch key {
+		case "cleanup.policy":
+			t.CleanupPolicy, err = parseCleanupPolicy(key, value)
+		case "retention.ms":
+			t.RetentionMs, err = parseInt64(key, value)
+		case "retention.bytes":
+			t.RetentionBytes, err = parseInt64(key, value)
+		case "delete.retention.ms":
+			t.DeleteRetentionMs, err = parseInt64(key, value)
+		case "segment.bytes":
+			t.SegmentBytes, err = parseInt32(key, value)
+			if err == nil && t.SegmentBytes < 14 {
+				err = fmt.Errorf("%s must be at least 14", key)
+			}
+		case "segment.ms":
+			t.SegmentMs, err = parseInt64(key, value)
+			if err == nil && t.SegmentMs <= 0 {
+				err = fmt.Errorf("%s must be positive", key)
+			}
+		}
+		if err != nil {
+			return TopicConfig{}, err
+		}
+	}
+	return t, nil
+}
+
+// Delete reports whether old segments of the topic are deleted by retention.
+func (t TopicConfig) Delete() bool {
+	return slices.Contains(t.CleanupPolicy, CleanupPolicyDelete)
+}
+
+// Compact reports whether the topic's log is compacted.
+func (t TopicConfig) Compact() bool {
+	return slices.Contains(t.CleanupPolicy, CleanupPolicyCompact)
+}
+
+func parseCleanupPolicy(key, value string) ([]string, error) {
+	policies := parseList(value)
+	for _, policy := range policies {
+		if policy != CleanupPolicyDelete && policy != CleanupPolicyCompact {
+			return nil, fmt.Errorf("%s: %q is neither %s nor %s", key, policy, CleanupPolicyDelete, CleanupPolicyCompact)
+		}
+	}
+	return policies, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTopicConfig(t *testing.T) {
+	c := Default()
+	defaults := TopicConfig{
+		CleanupPolicy:     []string{CleanupPolicyDelete},
+		RetentionMs:       c.LogRetentionMs,
+		RetentionBytes:    c.LogRetentionBytes,
+		DeleteRetentionMs: c.LogCleanerDeleteRetentionMs,
+		SegmentBytes:      c.LogSegmentBytes,
+		SegmentMs:         c.LogRollMs,
+	}
+	with := func(change func(t *TopicConfig)) TopicConfig {
+		topicConfig := defaults
+		change(&topicConfig)
+		return topicConfig
+	}
+	tests := []struct {
+		name    string
+		configs map[string]string
+		want    TopicConfig
+		wantErr string
+	}{
+		{name: "broker defaults", want: defaults},
+		{name: "unknown config", configs: map[string]string{"unknown.config": "x"}, want: defaults},
+		{
+			name: "cleanup.policy", configs: map[string]string{"cleanup.policy": "compact,delete"},
+			want: with(func(t *TopicConfig) { t.CleanupPolicy = []string{CleanupPolicyCompact, CleanupPolicyDelete} }),
+		},
+		{name: "retention.ms", configs: map[string]string{"retention.ms": "-1"}, want: with(func(t *TopicConfig) { t.RetentionMs = -1 })},
+		{name: "segment.bytes", configs: map[string]string{"segment.bytes": "1024"}, want: with(func(t *TopicConfig) { t.SegmentBytes = 1024 })},
+		{name: "segment.ms", configs: map[string]string{"segment.ms": "60000"}, want: with(func(t *TopicConfig) { t.SegmentMs = 60000 })},
+		{name: "invalid cleanup.policy", configs: map[string]string{"cleanup.policy": "archive"}, wantErr: "archive"},
+		{name: "segment.bytes too small", configs: map[string]string{"segment.bytes": "13"}, wantErr: "at least 14"},
+		{name: "segment.bytes over 32 bits", configs: map[string]string{"segment.bytes": "4294967296"}, wantErr: "not a 32-bit integer"},
+		{name: "segment.ms zero", configs: map[string]string{"segment.ms": "0"}, wantErr: "must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.TopicConfig(tt.configs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("topic config %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
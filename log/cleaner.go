@@ -0,0 +1,234 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"math"
+	"os"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+	"github.com/codecrafters-io/kafka-starter-go/record"
+)
+
+// cleanedFileSuffix marks a compacted copy of a segment that has yet to
+// replace it. Leftovers from an interrupted compaction are removed on open.
+const cleanedFileSuffix = ".cleaned"
+
+// CompactionResult describes what a compaction removed from a log.
+type CompactionResult struct {
+	SegmentsCleaned int
+	RecordsRemoved  int
+	BytesBefore     int64 // size of the rewritten segments before and after
+	BytesAfter      int64
+}
+
+// Compact rewrites the closed segments of the log, keeping only the latest
+// record of each key. Tombstones, records with a key and no value, are kept
+// until their timestamp is older than deleteRetentionMs as of now, so that
+// consumers get to see them first. Records keep their offsets: batches that
+// lose records are re-encoded with the same base and last offset, and
+// segments keep their base offset. Records without a key, control batches
+// and compressed batches are left as they are.
+//
+// Only logs whose active segment rolled since the last compaction, or that
+// kept a tombstone now older than deleteRetentionMs, are compacted. Closed
+// segments do not change, so they are read and their compacted copies
+// written without locking the log; it is only locked to swap the copies in.
+func (l *Log) Compact(deleteRetentionMs int64, now time.Time) (CompactionResult, error) {
+	l.cleanMu.Lock()
+	defer l.cleanMu.Unlock()
+
+	var result CompactionResult
+	l.mu.RLock()
+	active := l.activeSegment()
+	var closed []*segment
+	if active != nil {
+		closed = append(closed, l.segments[:len(l.segments)-1]...)
+	}
+	l.mu.RUnlock()
+	deleteHorizon := now.UnixMilli() - deleteRetentionMs
+	if active == nil || (active.baseOffset == l.compactedUpTo && l.oldestTombstone >= deleteHorizon) {
+		return result, nil
+	}
+
+	latest := make(map[string]int64)
+	for _, s := range closed {
+		err := s.forEachRecordBatch(func(_ []byte, batch *record.RecordBatch) error {
+			for _, r := range batch.Records {
+				if r.Key != nil {
+					latest[string(r.Key)] = batch.BaseOffset + r.OffsetDelta
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	oldestTombstone := int64(math.MaxInt64)
+	retain := func(batch *record.RecordBatch, r *record.Record) bool {
+		if r.Key == nil {
+			return true
+		}
+		if latest[string(r.Key)] != batch.BaseOffset+r.OffsetDelta {
+			return false
+		}
+		if r.Value != nil {
+			return true
+		}
+		timestamp := batch.FirstTimestamp + r.TimestampDelta
+		if timestamp < deleteHorizon {
+			return false
+		}
+		oldestTombstone = min(oldestTombstone, timestamp)
+		return true
+	}
+	removed := make([]int, len(closed))
+	for i, s := range closed {
+		var err error
+		if removed[i], err = s.writeCleaned(l.dir, retain); err != nil {
+			for _, s := range closed[:i] {
+				os.Remove(s.cleanedPath(l.dir))
+			}
+			return result, err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// retention is excluded by cleanMu, so the closed segments are still the
+	// first ones in the log
+	for i, s := range closed {
+		if removed[i] == 0 {
+			continue
+		}
+		cleaned, err := s.replaceWithCleaned(l.dir, l.config)
+		if cleaned != nil {
+			l.segments[i] = cleaned
+		}
+		if err != nil {
+			return result, err
+		}
+		result.SegmentsCleaned++
+		result.RecordsRemoved += removed[i]
+		result.BytesBefore += s.size
+		result.BytesAfter += cleaned.size
+	}
+	l.compactedUpTo = active.baseOffset
+	l.oldestTombstone = oldestTombstone
+	return result, nil
+}
+
+// forEachRecordBatch calls fn with each batch of the segment that can be
+// decoded, skipping control and compressed batches.
+func (s *segment) forEachRecordBatch(fn func(raw []byte, batch *record.RecordBatch) error) error {
+	return s.scan(0, func(position int64, header []byte) (bool, error) {
+		attributes := record.BatchAttributes(header)
+		if record.IsControlBatch(attributes) || record.IsCompressed(attributes) {
+			return true, nil
+		}
+		raw, err := s.readBatch(position, header)
+		if err != nil {
+			return false, err
+		}
+		dec := &decoder.BinaryDecoder{}
+		dec.Init(raw)
+		batch := &record.RecordBatch{}
+		if err = batch.Decode(dec); err != nil {
+			return false, err
+		}
+		return true, fn(raw, batch)
+	})
+}
+
+func (s *segment) cleanedPath(dir string) string {
+	return segmentFileName(dir, s.baseOffset, logFileSuffix+cleanedFileSuffix)
+}
+
+// writeCleaned writes a copy of the segment holding only the records that
+// retain accepts, and returns how many records were removed. The copy is left
+// for replaceWithCleaned only if records were removed.
+func (s *segment) writeCleaned(dir string, retain func(*record.RecordBatch, *record.Record) bool) (int, error) {
+	cleanedPath := s.cleanedPath(dir)
+	file, err := os.OpenFile(cleanedPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	w := bufio.NewWriter(file)
+	removed := 0
+	position := int64(0)
+	err = s.scan(0, func(batchPosition int64, header []byte) (bool, error) {
+		raw, err := s.readBatch(batchPosition, header)
+		if err != nil {
+			return false, err
+		}
+		position = batchPosition + int64(len(raw))
+		attributes := record.BatchAttributes(header)
+		if record.IsControlBatch(attributes) || record.IsCompressed(attributes) {
+			_, err = w.Write(raw)
+			return err == nil, err
+		}
+		dec := &decoder.BinaryDecoder{}
+		dec.Init(raw)
+		batch := record.RecordBatch{}
+		if err = batch.Decode(dec); err != nil {
+			return false, err
+		}
+		kept := batch.Records[:0]
+		for _, r := range batch.Records {
+			if retain(&batch, &r) {
+				kept = append(kept, r)
+			}
+		}
+		removed += len(batch.Records) - len(kept)
+		switch {
+		case len(kept) == len(batch.Records):
+			_, err = w.Write(raw)
+		case len(kept) > 0:
+			batch.Records = kept
+			enc := &encoder.BinaryEncoder{}
+			enc.Init(nil)
+			if err = batch.Encode(enc); err == nil {
+				_, err = w.Write(enc.Bytes())
+			}
+		}
+		return err == nil, err
+	})
+	if err == nil && position < s.size {
+		// a partial batch at the end is left for recovery to deal with
+		err = errors.New("segment ends with an incomplete batch")
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil && removed > 0 {
+		err = file.Sync()
+	}
+	err = errors.Join(err, file.Close())
+	if err != nil || removed == 0 {
+		return 0, errors.Join(err, os.Remove(cleanedPath))
+	}
+	return removed, nil
+}
+
+// replaceWithCleaned replaces the segment with the copy written by
+// writeCleaned, and returns the reopened segment.
+func (s *segment) replaceWithCleaned(dir string, config Config) (*segment, error) {
+	// the indexes are removed first, so that a crash before the rename leaves
+	// the old segment to have its indexes rebuilt
+	err := s.close()
+	for _, suffix := range []string{indexFileSuffix, timeIndexFileSuffix} {
+		if removeErr := os.Remove(segmentFileName(dir, s.baseOffset, suffix)); removeErr != nil && !os.IsNotExist(removeErr) {
+			err = errors.Join(err, removeErr)
+		}
+	}
+	if err == nil {
+		err = os.Rename(s.cleanedPath(dir), segmentFileName(dir, s.baseOffset, logFileSuffix))
+	}
+	// whichever file ended up in place is reopened, rebuilding its indexes
+	cleaned, openErr := openSegment(dir, s.baseOffset, config)
+	return cleaned, errors.Join(err, openErr)
+}
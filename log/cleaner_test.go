@@ -0,0 +1,287 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/record"
+)
+
+// kv returns a record with the given key and value; an empty key is null.
+func kv(key, value string) testRecord {
+	r := testRecord{value: []byte(value)}
+	if key != "" {
+		r.key = []byte(key)
+	}
+	return r
+}
+
+func tombstone(key string) testRecord {
+	return testRecord{key: []byte(key)}
+}
+
+// readRecords returns every record in the log as "offset:key=value", with
+// <null> for a null value.
+func readRecords(t *testing.T, l *Log) []string {
+	t.Helper()
+	var records []string
+	offset, next := l.Offsets()
+	for offset < next {
+		data, err := l.Read(offset, 1<<20, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		batches, _ := record.SplitBatches(data)
+		if len(batches) == 0 {
+			break
+		}
+		for _, raw := range batches {
+			dec := &decoder.BinaryDecoder{}
+			dec.Init(raw)
+			batch := record.RecordBatch{}
+			if err = batch.Decode(dec); err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range batch.Records {
+				value := "<null>"
+				if r.Value != nil {
+					value = string(r.Value)
+				}
+				records = append(records, fmt.Sprintf("%d:%s=%s", batch.BaseOffset+r.OffsetDelta, r.Key, value))
+			}
+			offset = record.BatchLastOffset(raw) + 1
+		}
+	}
+	return records
+}
+
+func TestCompact(t *testing.T) {
+	tests := []struct {
+		name              string
+		batches           [][]testRecord // the last one stays in the active segment
+		deleteRetentionMs int64
+		wantRecords       []string
+		wantRemoved       int
+		// wantLater are the records once the tombstones kept have expired,
+		// when a compaction without a roll removes them
+		wantLater []string
+	}{
+		{
+			name: "latest record of each key",
+			batches: [][]testRecord{
+				{kv("k1", "a"), kv("k2", "b")},
+				{kv("k1", "c")},
+				{kv("k3", "d"), kv("k2", "e")},
+				{kv("k1", "f")},
+			},
+			wantRecords: []string{"2:k1=c", "3:k3=d", "4:k2=e", "5:k1=f"},
+			wantRemoved: 2,
+		},
+		{
+			name: "records without a key",
+			batches: [][]testRecord{
+				{kv("", "a"), kv("k1", "b"), kv("", "c")},
+				{kv("k1", "d")},
+				{kv("", "e")},
+			},
+			wantRecords: []string{"0:=a", "2:=c", "3:k1=d", "4:=e"},
+			wantRemoved: 1,
+		},
+		{
+			name: "tombstone within delete retention",
+			batches: [][]testRecord{
+				{kv("k1", "a"), kv("k2", "b")},
+				{tombstone("k1")},
+				{kv("k2", "c")},
+			},
+			deleteRetentionMs: 1 << 40,
+			wantRecords:       []string{"1:k2=b", "2:k1=<null>", "3:k2=c"},
+			wantRemoved:       1,
+			wantLater:         []string{"1:k2=b", "3:k2=c"},
+		},
+		{
+			name: "tombstone past delete retention",
+			batches: [][]testRecord{
+				{kv("k1", "a"), kv("k2", "b")},
+				{tombstone("k1")},
+				{kv("k2", "c")},
+			},
+			wantRecords: []string{"1:k2=b", "3:k2=c"},
+			wantRemoved: 2,
+		},
+		{
+			name: "nothing to remove",
+			batches: [][]testRecord{
+				{kv("k1", "a")},
+				{kv("k2", "b")},
+				{kv("k1", "c")},
+			},
+			wantRecords: []string{"0:k1=a", "1:k2=b", "2:k1=c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			// every batch has a later timestamp than the one before, so each
+			// gets a segment of its own
+			config := Config{SegmentBytes: 1 << 20, SegmentMs: 0, IndexIntervalBytes: 1, SegmentIndexBytes: 1 << 20}
+			l := openLog(t, dir, config)
+			for i, records := range tt.batches {
+				appendBatches(t, l, encodeBatch(t, 1000+int64(i), records...))
+			}
+			if len(l.segments) != len(tt.batches) {
+				t.Fatalf("%d segments, want %d", len(l.segments), len(tt.batches))
+			}
+
+			result, err := l.Compact(tt.deleteRetentionMs, time.UnixMilli(10000))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.RecordsRemoved != tt.wantRemoved {
+				t.Fatalf("removed %d records, want %d", result.RecordsRemoved, tt.wantRemoved)
+			}
+			if tt.wantRemoved > 0 && (result.SegmentsCleaned == 0 || result.BytesAfter >= result.BytesBefore) {
+				t.Fatalf("compaction result %+v does not account for the removed records", result)
+			}
+			if got := readRecords(t, l); !slices.Equal(got, tt.wantRecords) {
+				t.Fatalf("records %v, want %v", got, tt.wantRecords)
+			}
+			wantNext := int64(0)
+			for _, records := range tt.batches {
+				wantNext += int64(len(records))
+			}
+			if start, next := l.Offsets(); start != 0 || next != wantNext {
+				t.Fatalf("offsets %d to %d, want 0 to %d", start, next, wantNext)
+			}
+			if leftovers, _ := filepath.Glob(filepath.Join(dir, "*"+cleanedFileSuffix)); len(leftovers) > 0 {
+				t.Fatalf("compaction left %v behind", leftovers)
+			}
+
+			result, err = l.Compact(0, time.UnixMilli(1<<40))
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantRecords := tt.wantRecords
+			if tt.wantLater != nil {
+				wantRecords = tt.wantLater
+				if result.RecordsRemoved != len(tt.wantRecords)-len(tt.wantLater) {
+					t.Fatalf("removed %d expired tombstones without a roll, want %d", result.RecordsRemoved, len(tt.wantRecords)-len(tt.wantLater))
+				}
+			} else if result != (CompactionResult{}) {
+				t.Fatalf("compacted again without a roll: %+v", result)
+			}
+			if err = l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			l = openLog(t, dir, config)
+			if got := readRecords(t, l); !slices.Equal(got, wantRecords) {
+				t.Fatalf("records after reopening %v, want %v", got, wantRecords)
+			}
+		})
+	}
+}
+
+// TestCompactExpiredTombstone checks that a tombstone kept by compaction is
+// removed once it passes the delete retention, though no segment rolled since.
+func TestCompactExpiredTombstone(t *testing.T) {
+	config := Config{SegmentBytes: 1 << 20, SegmentMs: 0, IndexIntervalBytes: 1, SegmentIndexBytes: 1 << 20}
+	l := openLog(t, t.TempDir(), config)
+	appendBatches(t, l,
+		encodeBatch(t, 1000, kv("k1", "a"), kv("k2", "b")),
+		encodeBatch(t, 2000, tombstone("k1")),
+		encodeBatch(t, 3000, kv("k2", "c")),
+	)
+	const deleteRetentionMs = 5000
+	tests := []struct {
+		name        string
+		now         int64
+		wantRemoved int
+		wantRecords []string
+	}{
+		{name: "tombstone kept", now: 4000, wantRemoved: 1, wantRecords: []string{"1:k2=b", "2:k1=<null>", "3:k2=c"}},
+		{name: "no roll and no expired tombstone", now: 7000, wantRecords: []string{"1:k2=b", "2:k1=<null>", "3:k2=c"}},
+		{name: "tombstone expired", now: 7001, wantRemoved: 1, wantRecords: []string{"1:k2=b", "3:k2=c"}},
+		{name: "no tombstone left", now: 1 << 40, wantRecords: []string{"1:k2=b", "3:k2=c"}},
+	}
+	for _, tt := range tests {
+		result, err := l.Compact(deleteRetentionMs, time.UnixMilli(tt.now))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.RecordsRemoved != tt.wantRemoved || (tt.wantRemoved == 0 && result != (CompactionResult{})) {
+			t.Fatalf("%s: compaction result %+v, want %d records removed", tt.name, result, tt.wantRemoved)
+		}
+		if got := readRecords(t, l); !slices.Equal(got, tt.wantRecords) {
+			t.Fatalf("%s: records %v, want %v", tt.name, got, tt.wantRecords)
+		}
+	}
+}
+
+func TestOpenRemovesCleanedFiles(t *testing.T) {
+	dir := t.TempDir()
+	config := Config{SegmentBytes: 1 << 20, SegmentMs: 1 << 40, IndexIntervalBytes: 1, SegmentIndexBytes: 1 << 20}
+	l := openLog(t, dir, config)
+	appendBatches(t, l, encodeBatch(t, 1000, kv("k1", "a")), encodeBatch(t, 1000, kv("k1", "b")))
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a compaction interrupted before the swap leaves a partial copy
+	cleanedPath := segmentFileName(dir, 0, logFileSuffix+cleanedFileSuffix)
+	if err := os.WriteFile(cleanedPath, []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l = openLog(t, dir, config)
+	if _, err := os.Stat(cleanedPath); !os.IsNotExist(err) {
+		t.Fatalf("%s was not removed on open", cleanedPath)
+	}
+	want := []string{"0:k1=a", "1:k1=b"}
+	if got := readRecords(t, l); !slices.Equal(got, want) {
+		t.Fatalf("records %v, want %v", got, want)
+	}
+}
+
+func TestCompactWhileAppending(t *testing.T) {
+	config := Config{SegmentBytes: 1 << 20, SegmentMs: 0, IndexIntervalBytes: 1, SegmentIndexBytes: 1 << 20}
+	l := openLog(t, t.TempDir(), config)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if _, err := l.Append([][]byte{encodeBatch(t, 1000+int64(i), kv(fmt.Sprint("k", i%2), fmt.Sprint(i)))}, false); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for compacting := true; compacting; {
+		select {
+		case <-done:
+			compacting = false
+		default:
+		}
+		if _, err := l.Compact(0, time.UnixMilli(0)); err != nil {
+			t.Fatal(err)
+		}
+		readRecords(t, l)
+	}
+
+	// a last compaction leaves the latest record of each key, with the one in
+	// the active segment kept regardless
+	if _, err := l.Append([][]byte{encodeBatch(t, 2000, kv("k2", "last"))}, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Compact(0, time.UnixMilli(0)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"98:k0=98", "99:k1=99", "100:k2=last"}
+	if got := readRecords(t, l); !slices.Equal(got, want) {
+		t.Fatalf("records %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,200 @@
+package log
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sort"
+)
+
+// The offset and time indexes of a segment are sparse: an entry is added once
+// IndexIntervalBytes of batches have been appended since the previous one.
+// Entries hold offsets relative to the segment's base offset, in the same
+// on-disk layout as Kafka's .index and .timeindex files.
+
+const (
+	offsetIndexEntrySize = 8  // relative offset (int32), position (int32)
+	timeIndexEntrySize   = 12 // timestamp (int64), relative offset (int32)
+)
+
+var errCorruptIndex = errors.New("corrupt index file")
+
+type offsetIndexEntry struct {
+	offset   int64
+	position int64
+}
+
+// offsetIndex maps offsets to the file position of the batch holding them. Each
+// entry names the last offset of a batch and the position the batch starts at.
+type offsetIndex struct {
+	file       *os.File
+	baseOffset int64
+	maxEntries int
+	entries    []offsetIndexEntry
+}
+
+func openOffsetIndex(path string, baseOffset int64, maxIndexBytes int) (*offsetIndex, error) {
+	file, raw, err := openIndexFile(path, offsetIndexEntrySize)
+	if err != nil {
+		return nil, err
+	}
+	index := &offsetIndex{file: file, baseOffset: baseOffset, maxEntries: maxIndexBytes / offsetIndexEntrySize}
+	for i := 0; i < len(raw); i += offsetIndexEntrySize {
+		entry := offsetIndexEntry{
+			offset:   baseOffset + int64(int32(binary.BigEndian.Uint32(raw[i:]))),
+			position: int64(int32(binary.BigEndian.Uint32(raw[i+4:]))),
+		}
+		last, ok := index.last()
+		if entry.offset < baseOffset || entry.position < 0 || ok && (entry.offset <= last.offset || entry.position <= last.position) {
+			file.Close()
+			return nil, errCorruptIndex
+		}
+		index.entries = append(index.entries, entry)
+	}
+	return index, nil
+}
+
+func (i *offsetIndex) last() (offsetIndexEntry, bool) {
+	if len(i.entries) == 0 {
+		return offsetIndexEntry{}, false
+	}
+	return i.entries[len(i.entries)-1], true
+}
+
+func (i *offsetIndex) full() bool {
+	return len(i.entries) >= i.maxEntries
+}
+
+func (i *offsetIndex) append(offset, position int64) error {
+	entry := make([]byte, offsetIndexEntrySize)
+	binary.BigEndian.PutUint32(entry, uint32(offset-i.baseOffset))
+	binary.BigEndian.PutUint32(entry[4:], uint32(position))
+	if _, err := i.file.WriteAt(entry, int64(len(i.entries)*offsetIndexEntrySize)); err != nil {
+		return err
+	}
+	i.entries = append(i.entries, offsetIndexEntry{offset: offset, position: position})
+	return nil
+}
+
+// lookup returns the position to start scanning from for the batch holding
+// offset: that of the last entry below offset, or the start of the segment.
+func (i *offsetIndex) lookup(offset int64) int64 {
+	n := sort.Search(len(i.entries), func(j int) bool { return i.entries[j].offset >= offset })
+	if n == 0 {
+		return 0
+	}
+	return i.entries[n-1].position
+}
+
+// truncate drops the entries for positions at or after position.
+func (i *offsetIndex) truncate(position int64) error {
+	n := sort.Search(len(i.entries), func(j int) bool { return i.entries[j].position >= position })
+	if err := i.file.Truncate(int64(n * offsetIndexEntrySize)); err != nil {
+		return err
+	}
+	i.entries = i.entries[:n]
+	return nil
+}
+
+type timeIndexEntry struct {
+	timestamp int64
+	offset    int64
+}
+
+// timeIndex maps timestamps to offsets. Each entry holds the largest timestamp
+// seen so far in the segment and the offset of the batch carrying it, so
+// entries increase in both.
+type timeIndex struct {
+	file       *os.File
+	baseOffset int64
+	maxEntries int
+	entries    []timeIndexEntry
+}
+
+func openTimeIndex(path string, baseOffset int64, maxIndexBytes int) (*timeIndex, error) {
+	file, raw, err := openIndexFile(path, timeIndexEntrySize)
+	if err != nil {
+		return nil, err
+	}
+	index := &timeIndex{file: file, baseOffset: baseOffset, maxEntries: maxIndexBytes / timeIndexEntrySize}
+	for i := 0; i < len(raw); i += timeIndexEntrySize {
+		entry := timeIndexEntry{
+			timestamp: int64(binary.BigEndian.Uint64(raw[i:])),
+			offset:    baseOffset + int64(int32(binary.BigEndian.Uint32(raw[i+8:]))),
+		}
+		last, ok := index.last()
+		if entry.offset < baseOffset || ok && (entry.timestamp <= last.timestamp || entry.offset < last.offset) {
+			file.Close()
+			return nil, errCorruptIndex
+		}
+		index.entries = append(index.entries, entry)
+	}
+	return index, nil
+}
+
+func (i *timeIndex) last() (timeIndexEntry, bool) {
+	if len(i.entries) == 0 {
+		return timeIndexEntry{}, false
+	}
+	return i.entries[len(i.entries)-1], true
+}
+
+func (i *timeIndex) full() bool {
+	return len(i.entries) >= i.maxEntries
+}
+
+// maybeAppend adds an entry if timestamp is larger than that of the last entry.
+func (i *timeIndex) maybeAppend(timestamp, offset int64) error {
+	if last, ok := i.last(); ok && timestamp <= last.timestamp {
+		return nil
+	}
+	entry := make([]byte, timeIndexEntrySize)
+	binary.BigEndian.PutUint64(entry, uint64(timestamp))
+	binary.BigEndian.PutUint32(entry[8:], uint32(offset-i.baseOffset))
+	if _, err := i.file.WriteAt(entry, int64(len(i.entries)*timeIndexEntrySize)); err != nil {
+		return err
+	}
+	i.entries = append(i.entries, timeIndexEntry{timestamp: timestamp, offset: offset})
+	return nil
+}
+
+// lookup returns the offset to start searching from for the first batch with a
+// timestamp at or after timestamp: that of the last entry below timestamp, or
+// the base offset of the segment.
+func (i *timeIndex) lookup(timestamp int64) int64 {
+	n := sort.Search(len(i.entries), func(j int) bool { return i.entries[j].timestamp >= timestamp })
+	if n == 0 {
+		return i.baseOffset
+	}
+	return i.entries[n-1].offset
+}
+
+// truncate drops the entries for offsets at or after offset.
+func (i *timeIndex) truncate(offset int64) error {
+	n := sort.Search(len(i.entries), func(j int) bool { return i.entries[j].offset >= offset })
+	if err := i.file.Truncate(int64(n * timeIndexEntrySize)); err != nil {
+		return err
+	}
+	i.entries = i.entries[:n]
+	return nil
+}
+
+// openIndexFile opens or creates an index file and returns its contents, which
+// must be whole entries.
+func openIndexFile(path string, entrySize int) (*os.File, []byte, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if len(raw)%entrySize != 0 {
+		file.Close()
+		return nil, nil, errCorruptIndex
+	}
+	return file, raw, nil
+}
@@ -0,0 +1,357 @@
+// Package log stores the records of a topic partition as a sequence of
+// segment files named by the offset of their first record, in the same
+// on-disk layout as Kafka.
+package log
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/record"
+)
+
+// ErrClosed is returned by Append once the log is closed.
+var ErrClosed = errors.New("log is closed")
+
+// ErrInvalidBatch is returned by Append for batches whose offsets cannot be
+// assigned, such as a negative last offset delta.
+var ErrInvalidBatch = errors.New("invalid record batch")
+
+// Config controls how a log is split into segments.
+type Config struct {
+	// SegmentBytes is the size a segment may grow to before a new one is rolled.
+	SegmentBytes int64
+	// SegmentMs is how long a segment may receive batches before a new one is rolled.
+	SegmentMs int64
+	// IndexIntervalBytes is how many bytes are appended between index entries.
+	IndexIntervalBytes int
+	// SegmentIndexBytes is the size an index may grow to before a new segment is rolled.
+	SegmentIndexBytes int
+}
+
+// Log is the log of a single topic partition. Batches are appended to the last
+// segment, which is replaced by a new one once it grows too large or old.
+type Log struct {
+	mu sync.RWMutex
+	// cleanMu is held by compaction, retention and Close, which must not run
+	// at the same time since compaction reads closed segments without mu.
+	cleanMu  sync.Mutex
+	dir      string
+	config   Config
+	segments []*segment    // ordered by base offset; empty until the first append
+	appended chan struct{} // closed and replaced after every append
+	// compactedUpTo is the base offset of the active segment as of the last
+	// compaction, which covered every segment before it. Guarded by cleanMu.
+	compactedUpTo int64
+	// oldestTombstone is the timestamp of the oldest tombstone the last
+	// compaction kept, or math.MaxInt64 if it kept none. Once it falls out of
+	// the delete retention, the log is compacted again. Guarded by cleanMu.
+	oldestTombstone int64
+	closed          bool
+}
+
+// Open opens the log stored in dir. The directory is created on the first append.
+func Open(dir string, config Config) (*Log, error) {
+	l := &Log{dir: dir, config: config, appended: make(chan struct{}), oldestTombstone: math.MaxInt64}
+	baseOffsets, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err = removeCleanedFiles(dir); err != nil {
+		return nil, err
+	}
+	for _, baseOffset := range baseOffsets {
+		s, err := openSegment(dir, baseOffset, config)
+		if err != nil {
+			l.Close()
+			return nil, fmt.Errorf("opening segment %d of %s: %w", baseOffset, dir, err)
+		}
+		l.segments = append(l.segments, s)
+	}
+	return l, nil
+}
+
+// listSegments returns the base offsets of the segments in dir, in order.
+func listSegments(dir string) ([]int64, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var baseOffsets []int64
+	for _, entry := range entries {
+		name, found := strings.CutSuffix(entry.Name(), logFileSuffix)
+		if !found || entry.IsDir() {
+			continue
+		}
+		baseOffset, err := strconv.ParseInt(name, 10, 64)
+		if err != nil || baseOffset < 0 {
+			continue
+		}
+		baseOffsets = append(baseOffsets, baseOffset)
+	}
+	sort.Slice(baseOffsets, func(i, j int) bool { return baseOffsets[i] < baseOffsets[j] })
+	return baseOffsets, nil
+}
+
+// removeCleanedFiles removes the leftovers of a compaction that was interrupted
+// before the compacted segments replaced the originals.
+func removeCleanedFiles(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+cleanedFileSuffix))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err = os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetConfig replaces the settings of the log, such as after the topic's
+// configs changed. They apply from the next append on.
+func (l *Log) SetConfig(config Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = config
+}
+
+// Dir returns the directory the log is stored in.
+func (l *Log) Dir() string {
+	return l.dir
+}
+
+func (l *Log) activeSegment() *segment {
+	if len(l.segments) == 0 {
+		return nil
+	}
+	return l.segments[len(l.segments)-1]
+}
+
+// Append assigns consecutive offsets to the given record batches and writes them
+// to the end of the log. It returns the base offset of the first batch.
+func (l *Log) Append(batches [][]byte, fsync bool) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, ErrClosed
+	}
+
+	baseOffset := l.nextOffset()
+	nextOffset := baseOffset
+	size := 0
+	largestTimestamp := int64(noTimestamp)
+	assigned := make([][]byte, len(batches))
+	for i, batch := range batches {
+		batch = append([]byte(nil), batch...)
+		lastOffsetDelta := record.BatchLastOffset(batch) - record.BatchBaseOffset(batch)
+		// a negative delta would move the next offset backwards
+		if lastOffsetDelta < 0 {
+			return 0, fmt.Errorf("%w: last offset delta %d", ErrInvalidBatch, lastOffsetDelta)
+		}
+		record.SetBatchBaseOffset(batch, nextOffset)
+		nextOffset += lastOffsetDelta + 1
+		size += len(batch)
+		largestTimestamp = max(largestTimestamp, record.BatchMaxTimestamp(batch))
+		assigned[i] = batch
+	}
+
+	s, err := l.maybeRoll(baseOffset, nextOffset-1, size, largestTimestamp)
+	if err != nil {
+		return 0, err
+	}
+	if err = s.append(assigned, l.config); err != nil {
+		return 0, err
+	}
+	if fsync {
+		if err = s.sync(); err != nil {
+			return 0, err
+		}
+	}
+
+	close(l.appended)
+	l.appended = make(chan struct{})
+	return baseOffset, nil
+}
+
+// maybeRoll returns the segment to append the given batches to, rolling a new
+// one if the active segment cannot take them.
+func (l *Log) maybeRoll(baseOffset, lastOffset int64, size int, largestTimestamp int64) (*segment, error) {
+	s := l.activeSegment()
+	if s != nil && !l.shouldRoll(s, lastOffset, size, largestTimestamp) {
+		return s, nil
+	}
+	if s != nil {
+		if err := s.onBecomeInactive(); err != nil {
+			return nil, err
+		}
+		if err := s.sync(); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return nil, err
+	}
+	s, err := createSegment(l.dir, baseOffset, l.config)
+	if err != nil {
+		return nil, err
+	}
+	l.segments = append(l.segments, s)
+	return s, nil
+}
+
+func (l *Log) shouldRoll(s *segment, lastOffset int64, size int, largestTimestamp int64) bool {
+	if s.size == 0 {
+		return false
+	}
+	return s.size+int64(size) > l.config.SegmentBytes ||
+		s.timeWaitedForRoll(time.Now(), largestTimestamp) > l.config.SegmentMs ||
+		s.index.full() || s.timeIndex.full() ||
+		// offsets in the indexes are stored relative to the base offset as int32
+		lastOffset-s.baseOffset > math.MaxInt32
+}
+
+func (l *Log) nextOffset() int64 {
+	if s := l.activeSegment(); s != nil {
+		return s.nextOffset
+	}
+	return 0
+}
+
+// Appended returns a channel that is closed by the next append to the log.
+func (l *Log) Appended() <-chan struct{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.appended
+}
+
+// Offsets returns the first offset in the log and the offset the next record
+// will be assigned, which doubles as the high watermark on a single broker.
+func (l *Log) Offsets() (logStartOffset int64, highWatermark int64) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if len(l.segments) > 0 {
+		logStartOffset = l.segments[0].baseOffset
+	}
+	return logStartOffset, l.nextOffset()
+}
+
+// Read returns whole raw record batches starting with the one containing offset,
+// stopping before maxBytes would be exceeded. If minOneBatch is set the first
+// batch is returned even when it is larger than maxBytes, so consumers can
+// make progress past it. Batches are read from a single segment.
+func (l *Log) Read(offset int64, maxBytes int, minOneBatch bool) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	i := sort.Search(len(l.segments), func(i int) bool { return l.segments[i].baseOffset > offset })
+	for i = max(i-1, 0); i < len(l.segments); i++ {
+		data, err := l.segments[i].read(offset, maxBytes, minOneBatch)
+		if data != nil || err != nil {
+			return data, err
+		}
+	}
+	return []byte{}, nil
+}
+
+// FindBatchByTimestamp returns the first batch holding a record with a
+// timestamp at or after timestamp, or nil if there is none.
+func (l *Log) FindBatchByTimestamp(timestamp int64) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, s := range l.segments {
+		if s.maxTimestamp < timestamp {
+			continue
+		}
+		batch, err := s.findByTimestamp(timestamp)
+		if batch != nil || err != nil {
+			return batch, err
+		}
+	}
+	return nil, nil
+}
+
+// FindBatchWithMaxTimestamp returns the first batch holding the largest
+// timestamp in the log, or nil if the log is empty.
+func (l *Log) FindBatchWithMaxTimestamp() ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var found *segment
+	for _, s := range l.segments {
+		if s.maxTimestamp != noTimestamp && (found == nil || s.maxTimestamp > found.maxTimestamp) {
+			found = s
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+	return found.read(found.offsetOfMaxTimestamp, 0, true)
+}
+
+// Sync flushes the active segment and its indexes to disk, so that appends made
+// without fsync survive a crash. Closed segments are synced when rolled.
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s := l.activeSegment(); s != nil {
+		return s.sync()
+	}
+	return nil
+}
+
+// Close syncs the active segment and closes the files of every segment. Appends
+// fail with ErrClosed from then on, and the log reads as empty.
+func (l *Log) Close() error {
+	l.cleanMu.Lock()
+	defer l.cleanMu.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	var firstErr error
+	if s := l.activeSegment(); s != nil {
+		firstErr = s.sync()
+	}
+	for _, s := range l.segments {
+		if err := s.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	l.segments = nil
+	return firstErr
+}
+
+// ScanDir calls fn with each complete batch of the log stored in dir, in offset
+// order, without opening the log for appends. It suits logs written by another
+// process, such as the cluster metadata log.
+func ScanDir(dir string, fn func(batch []byte) error) error {
+	baseOffsets, err := listSegments(dir)
+	if err != nil {
+		return err
+	}
+	for _, baseOffset := range baseOffsets {
+		data, err := os.ReadFile(segmentFileName(dir, baseOffset, logFileSuffix))
+		if err != nil {
+			return err
+		}
+		batches, _ := record.SplitBatches(data)
+		for _, batch := range batches {
+			if err = fn(batch); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
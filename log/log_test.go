@@ -0,0 +1,345 @@
+package log
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+	"github.com/codecrafters-io/kafka-starter-go/record"
+)
+
+// testRecord is a record to put in a test batch; a nil key or value is null.
+type testRecord struct {
+	key   []byte
+	value []byte
+}
+
+// encodeBatch returns an uncompressed batch holding records, all with timestamp.
+func encodeBatch(t *testing.T, timestamp int64, records ...testRecord) []byte {
+	t.Helper()
+	batch := record.RecordBatch{
+		Magic:           2,
+		LastOffsetDelta: int32(len(records) - 1),
+		FirstTimestamp:  timestamp,
+		MaxTimestamp:    timestamp,
+		ProducerId:      -1,
+		ProducerEpoch:   -1,
+		BaseSequence:    -1,
+	}
+	for i, r := range records {
+		rec := record.Record{OffsetDelta: int64(i), KeyLength: -1, Key: r.key, ValueLength: -1, Value: r.value}
+		if r.key != nil {
+			rec.KeyLength = int64(len(r.key))
+		}
+		if r.value != nil {
+			rec.ValueLength = int64(len(r.value))
+		}
+		rec.Length = rec.GetEncodedLength()
+		batch.Records = append(batch.Records, rec)
+	}
+	enc := encoder.BinaryEncoder{}
+	enc.Init(nil)
+	if err := batch.Encode(&enc); err != nil {
+		t.Fatal(err)
+	}
+	return enc.Bytes()
+}
+
+// valueBatch returns a batch of keyless records with the given values.
+func valueBatch(t *testing.T, timestamp int64, values ...string) []byte {
+	t.Helper()
+	records := make([]testRecord, len(values))
+	for i, value := range values {
+		records[i] = testRecord{value: []byte(value)}
+	}
+	return encodeBatch(t, timestamp, records...)
+}
+
+func openLog(t *testing.T, dir string, config Config) *Log {
+	t.Helper()
+	l, err := Open(dir, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func appendBatches(t *testing.T, l *Log, batches ...[]byte) {
+	t.Helper()
+	for _, batch := range batches {
+		if _, err := l.Append([][]byte{batch}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkRead reads every offset from first up to next and checks that the first
+// batch returned holds it.
+func checkRead(t *testing.T, l *Log, first, next int64) {
+	t.Helper()
+	for offset := first; offset < next; offset++ {
+		data, err := l.Read(offset, 1, true)
+		if err != nil {
+			t.Fatalf("reading offset %d: %v", offset, err)
+		}
+		if len(data) < record.BatchHeaderLength {
+			t.Fatalf("reading offset %d: got %d bytes", offset, len(data))
+		}
+		if base, last := record.BatchBaseOffset(data), record.BatchLastOffset(data); offset < base || offset > last {
+			t.Fatalf("reading offset %d: got batch with offsets %d to %d", offset, base, last)
+		}
+	}
+}
+
+func segmentBaseOffsets(l *Log) []int64 {
+	var baseOffsets []int64
+	for _, s := range l.segments {
+		baseOffsets = append(baseOffsets, s.baseOffset)
+	}
+	return baseOffsets
+}
+
+func TestLogRollAndReopen(t *testing.T) {
+	batchSize := int64(len(valueBatch(t, 0, "value")))
+	tests := []struct {
+		name   string
+		config Config
+		// timestampStep is the timestamp difference between consecutive batches
+		timestampStep   int64
+		wantBaseOffsets []int64
+	}{
+		{
+			name:            "single segment",
+			config:          Config{SegmentBytes: 1 << 20, SegmentMs: 1 << 40, IndexIntervalBytes: 4096, SegmentIndexBytes: 1 << 20},
+			wantBaseOffsets: []int64{0},
+		},
+		{
+			name:            "roll on size",
+			config:          Config{SegmentBytes: 3 * batchSize, SegmentMs: 1 << 40, IndexIntervalBytes: 4096, SegmentIndexBytes: 1 << 20},
+			wantBaseOffsets: []int64{0, 3, 6, 9},
+		},
+		{
+			name:            "roll on time",
+			config:          Config{SegmentBytes: 1 << 20, SegmentMs: 2500, IndexIntervalBytes: 4096, SegmentIndexBytes: 1 << 20},
+			timestampStep:   1000,
+			wantBaseOffsets: []int64{0, 3, 6, 9},
+		},
+		{
+			// the offset index fits 3 entries, one for each batch after the first
+			name:            "roll on full index",
+			config:          Config{SegmentBytes: 1 << 20, SegmentMs: 1 << 40, IndexIntervalBytes: 1, SegmentIndexBytes: 3 * offsetIndexEntrySize},
+			wantBaseOffsets: []int64{0, 4, 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			l := openLog(t, dir, tt.config)
+			for i := int64(0); i < 10; i++ {
+				appendBatches(t, l, valueBatch(t, 1000+i*tt.timestampStep, "value"))
+			}
+			if got := segmentBaseOffsets(l); !slices.Equal(got, tt.wantBaseOffsets) {
+				t.Fatalf("segments %v, want %v", got, tt.wantBaseOffsets)
+			}
+			checkRead(t, l, 0, 10)
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			l = openLog(t, dir, tt.config)
+			if got := segmentBaseOffsets(l); !slices.Equal(got, tt.wantBaseOffsets) {
+				t.Fatalf("segments after reopening %v, want %v", got, tt.wantBaseOffsets)
+			}
+			if start, next := l.Offsets(); start != 0 || next != 10 {
+				t.Fatalf("offsets after reopening %d to %d, want 0 to 10", start, next)
+			}
+			checkRead(t, l, 0, 10)
+			baseOffset, err := l.Append([][]byte{valueBatch(t, 1000+10*tt.timestampStep, "a", "b")}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if baseOffset != 10 {
+				t.Fatalf("appended at %d after reopening, want 10", baseOffset)
+			}
+			checkRead(t, l, 0, 12)
+		})
+	}
+}
+
+func TestLogSparseIndex(t *testing.T) {
+	batchSize := len(valueBatch(t, 0, "value"))
+	tests := []struct {
+		name               string
+		indexIntervalBytes int
+		wantEntries        int
+	}{
+		{name: "every batch", indexIntervalBytes: 1, wantEntries: 19},
+		{name: "every third batch", indexIntervalBytes: 2 * batchSize, wantEntries: 6},
+		{name: "no entries", indexIntervalBytes: 1 << 20, wantEntries: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{SegmentBytes: 1 << 20, SegmentMs: 1 << 40, IndexIntervalBytes: tt.indexIntervalBytes, SegmentIndexBytes: 1 << 20}
+			l := openLog(t, t.TempDir(), config)
+			for i := int64(0); i < 20; i++ {
+				appendBatches(t, l, valueBatch(t, 1000+i, "value"))
+			}
+			if got := len(l.segments[0].index.entries); got != tt.wantEntries {
+				t.Fatalf("%d index entries, want %d", got, tt.wantEntries)
+			}
+			if got := len(l.segments[0].timeIndex.entries); got != tt.wantEntries {
+				t.Fatalf("%d time index entries, want %d", got, tt.wantEntries)
+			}
+			checkRead(t, l, 0, 20)
+			for i := int64(0); i < 20; i++ {
+				batch, err := l.FindBatchByTimestamp(1000 + i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if batch == nil || record.BatchBaseOffset(batch) != i {
+					t.Fatalf("timestamp %d: got batch %x, want offset %d", 1000+i, batch, i)
+				}
+			}
+		})
+	}
+}
+
+func TestLogRebuildsIndexes(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, dir string)
+	}{
+		{
+			name: "missing indexes",
+			corrupt: func(t *testing.T, dir string) {
+				for _, suffix := range []string{indexFileSuffix, timeIndexFileSuffix} {
+					if err := os.Remove(segmentFileName(dir, 0, suffix)); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+		},
+		{
+			name: "offsets out of order",
+			corrupt: func(t *testing.T, dir string) {
+				entry := make([]byte, offsetIndexEntrySize)
+				writeIndexEntry(t, segmentFileName(dir, 0, indexFileSuffix), entry)
+			},
+		},
+		{
+			name: "negative position",
+			corrupt: func(t *testing.T, dir string) {
+				entry := make([]byte, offsetIndexEntrySize)
+				binary.BigEndian.PutUint32(entry, 100)
+				binary.BigEndian.PutUint32(entry[4:], 0xffffffff)
+				writeIndexEntry(t, segmentFileName(dir, 0, indexFileSuffix), entry)
+			},
+		},
+		{
+			name: "position past the end",
+			corrupt: func(t *testing.T, dir string) {
+				entry := make([]byte, offsetIndexEntrySize)
+				binary.BigEndian.PutUint32(entry, 100)
+				binary.BigEndian.PutUint32(entry[4:], 1<<30)
+				writeIndexEntry(t, segmentFileName(dir, 0, indexFileSuffix), entry)
+			},
+		},
+		{
+			name: "timestamps out of order",
+			corrupt: func(t *testing.T, dir string) {
+				entry := make([]byte, timeIndexEntrySize)
+				binary.BigEndian.PutUint32(entry[8:], 15)
+				writeIndexEntry(t, segmentFileName(dir, 0, timeIndexFileSuffix), entry)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			config := Config{SegmentBytes: 1 << 20, SegmentMs: 1 << 40, IndexIntervalBytes: 1, SegmentIndexBytes: 1 << 20}
+			l := openLog(t, dir, config)
+			for i := int64(0); i < 20; i++ {
+				appendBatches(t, l, valueBatch(t, 1000+i, "value"))
+			}
+			wantIndex := append([]offsetIndexEntry(nil), l.segments[0].index.entries...)
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.corrupt(t, dir)
+			l = openLog(t, dir, config)
+			if got := l.segments[0].index.entries; len(got) != len(wantIndex) {
+				t.Fatalf("%d index entries after rebuilding, want %d", len(got), len(wantIndex))
+			}
+			for i, entry := range l.segments[0].index.entries {
+				if entry != wantIndex[i] {
+					t.Fatalf("index entry %d is %+v after rebuilding, want %+v", i, entry, wantIndex[i])
+				}
+			}
+			checkRead(t, l, 0, 20)
+			batch, err := l.FindBatchWithMaxTimestamp()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if batch == nil || record.BatchBaseOffset(batch) != 19 {
+				t.Fatalf("batch with max timestamp %x, want offset 19", batch)
+			}
+		})
+	}
+}
+
+// writeIndexEntry appends a raw entry to the index file at path.
+func writeIndexEntry(t *testing.T, path string, entry []byte) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err = file.Write(entry); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogAppendRejectsNegativeLastOffsetDelta(t *testing.T) {
+	l := openLog(t, t.TempDir(), Config{SegmentBytes: 1 << 20, SegmentMs: 1 << 40, IndexIntervalBytes: 1, SegmentIndexBytes: 1 << 20})
+	appendBatches(t, l, valueBatch(t, 1000, "a", "b"))
+
+	batch := valueBatch(t, 1000, "c")
+	binary.BigEndian.PutUint32(batch[23:], uint32(0xfffffff1)) // last offset delta -15
+	if _, err := l.Append([][]byte{valueBatch(t, 1000, "d"), batch}, false); !errors.Is(err, ErrInvalidBatch) {
+		t.Fatalf("Append returned %v, want ErrInvalidBatch", err)
+	}
+	if start, next := l.Offsets(); start != 0 || next != 2 {
+		t.Fatalf("offsets %d to %d, want 0 to 2", start, next)
+	}
+	baseOffset, err := l.Append([][]byte{valueBatch(t, 1000, "e")}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if baseOffset != 2 {
+		t.Fatalf("appended at %d, want 2", baseOffset)
+	}
+	checkRead(t, l, 0, 3)
+}
+
+func TestLogAppendAfterClose(t *testing.T) {
+	dir := t.TempDir()
+	l := openLog(t, dir, Config{SegmentBytes: 1 << 20, SegmentMs: 1 << 40, IndexIntervalBytes: 1, SegmentIndexBytes: 1 << 20})
+	appendBatches(t, l, valueBatch(t, 1000, "a"))
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Append([][]byte{valueBatch(t, 1000, "b")}, false); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Append after Close returned %v, want ErrClosed", err)
+	}
+
+	l = openLog(t, dir, Config{SegmentBytes: 1 << 20, SegmentMs: 1 << 40, IndexIntervalBytes: 1, SegmentIndexBytes: 1 << 20})
+	if start, next := l.Offsets(); start != 0 || next != 1 {
+		t.Fatalf("offsets after reopening %d to %d, want 0 to 1", start, next)
+	}
+}
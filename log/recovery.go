@@ -0,0 +1,70 @@
+package log
+
+import "github.com/codecrafters-io/kafka-starter-go/record"
+
+// Recover checks the batches of the active segment, which a crash may have left
+// partly written, and truncates it at the first batch that is incomplete,
+// fails validation or goes back in offsets. The segment's indexes are
+// rebuilt from the batches that remain. It returns how many bytes were
+// truncated. Closed segments were synced when rolled, so are left alone.
+func (l *Log) Recover() (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := l.activeSegment()
+	if s == nil {
+		return 0, nil
+	}
+	return s.recover(l.config)
+}
+
+func (s *segment) recover(config Config) (int64, error) {
+	if err := s.index.truncate(0); err != nil {
+		return 0, err
+	}
+	if err := s.timeIndex.truncate(s.baseOffset); err != nil {
+		return 0, err
+	}
+	s.nextOffset = s.baseOffset
+	s.maxTimestamp, s.offsetOfMaxTimestamp = noTimestamp, 0
+	s.rollingTimestamp = noTimestamp
+	s.bytesSinceIndexEntry = 0
+
+	validSize := int64(0)
+	err := s.scan(0, func(position int64, header []byte) (bool, error) {
+		batch, err := s.readBatch(position, header)
+		if err != nil {
+			return false, err
+		}
+		if !s.validBatch(batch) {
+			return false, nil
+		}
+		if s.rollingTimestamp == noTimestamp {
+			s.rollingTimestamp = record.BatchMaxTimestamp(batch)
+		}
+		if err = s.indexBatch(position, batch, config); err != nil {
+			return false, err
+		}
+		validSize = position + int64(len(batch))
+		return true, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	truncated := s.size - validSize
+	if truncated > 0 {
+		if err = s.file.Truncate(validSize); err != nil {
+			return 0, err
+		}
+		s.size = validSize
+	}
+	return truncated, nil
+}
+
+// validBatch reports whether a batch read back from the segment is intact and
+// follows the batches before it.
+func (s *segment) validBatch(batch []byte) bool {
+	return record.ValidateBatch(batch) == nil &&
+		record.BatchBaseOffset(batch) >= s.nextOffset &&
+		record.BatchLastOffset(batch) >= record.BatchBaseOffset(batch)
+}
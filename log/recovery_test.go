@@ -0,0 +1,116 @@
+package log
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	batchSize := int64(len(valueBatch(t, 0, "value")))
+	tests := []struct {
+		name string
+		// damage changes the active segment as an unclean shutdown could
+		damage        func(t *testing.T, path string)
+		wantTruncated int64
+		wantNext      int64
+	}{
+		{
+			name:     "intact",
+			damage:   func(t *testing.T, path string) {},
+			wantNext: 5,
+		},
+		{
+			name: "partial batch",
+			damage: func(t *testing.T, path string) {
+				appendToFile(t, path, valueBatch(t, 1000, "value")[:30])
+			},
+			wantTruncated: 30,
+			wantNext:      5,
+		},
+		{
+			name: "corrupt last batch",
+			damage: func(t *testing.T, path string) {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				data[len(data)-1] ^= 0xff
+				if err = os.WriteFile(path, data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantTruncated: batchSize,
+			wantNext:      4,
+		},
+		{
+			name: "offsets going back",
+			damage: func(t *testing.T, path string) {
+				// batches written by Append start at offset 0 unless assigned one
+				appendToFile(t, path, valueBatch(t, 1000, "value"))
+			},
+			wantTruncated: batchSize,
+			wantNext:      5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			config := Config{SegmentBytes: 1 << 20, SegmentMs: 1 << 40, IndexIntervalBytes: 1, SegmentIndexBytes: 1 << 20}
+			l := openLog(t, dir, config)
+			for i := int64(0); i < 5; i++ {
+				appendBatches(t, l, valueBatch(t, 1000+i, "value"))
+			}
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			path := segmentFileName(dir, 0, logFileSuffix)
+			tt.damage(t, path)
+			l = openLog(t, dir, config)
+			truncated, err := l.Recover()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if truncated != tt.wantTruncated {
+				t.Fatalf("truncated %d bytes, want %d", truncated, tt.wantTruncated)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size() != tt.wantNext*batchSize {
+				t.Fatalf("segment is %d bytes after recovery, want %d", info.Size(), tt.wantNext*batchSize)
+			}
+			if _, next := l.Offsets(); next != tt.wantNext {
+				t.Fatalf("next offset %d after recovery, want %d", next, tt.wantNext)
+			}
+			checkRead(t, l, 0, tt.wantNext)
+
+			baseOffset, err := l.Append([][]byte{valueBatch(t, 2000, "value")}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if baseOffset != tt.wantNext {
+				t.Fatalf("appended at %d after recovery, want %d", baseOffset, tt.wantNext)
+			}
+			if err = l.Close(); err != nil {
+				t.Fatal(err)
+			}
+			l = openLog(t, dir, config)
+			checkRead(t, l, 0, tt.wantNext+1)
+		})
+	}
+}
+
+// appendToFile writes data to the end of the file at path.
+func appendToFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err = file.Write(data); err != nil {
+		t.Fatal(err)
+	}
+}
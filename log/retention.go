@@ -0,0 +1,57 @@
+package log
+
+import "time"
+
+// DeletedSegment describes a segment removed from a log by retention.
+type DeletedSegment struct {
+	BaseOffset int64
+	NextOffset int64 // one past the last offset the segment held
+	Size       int64
+	Reason     string // the retention setting that was breached
+}
+
+// ApplyRetention deletes the oldest closed segments whose newest record is
+// older than retentionMs, or that take the log over retentionBytes, as of now.
+// A limit of -1 disables it. The active segment is never deleted, so the log
+// keeps its next offset; the log start offset advances past the deleted ones.
+func (l *Log) ApplyRetention(retentionMs, retentionBytes int64, now time.Time) ([]DeletedSegment, error) {
+	l.cleanMu.Lock()
+	defer l.cleanMu.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	excessBytes := int64(-1)
+	if retentionBytes >= 0 {
+		excessBytes = -retentionBytes
+		for _, s := range l.segments {
+			excessBytes += s.size
+		}
+	}
+
+	var deleted []DeletedSegment
+	for len(l.segments) > 1 {
+		s := l.segments[0]
+		var reason string
+		largestTimestamp, err := s.largestTimestamp()
+		if err != nil {
+			return deleted, err
+		}
+		if retentionMs >= 0 && now.UnixMilli()-largestTimestamp > retentionMs {
+			reason = "retention.ms"
+		} else if retentionBytes >= 0 && excessBytes >= s.size {
+			reason = "retention.bytes"
+		} else {
+			break
+		}
+
+		// the segment is dropped from the log first so that a failure to remove
+		// its files leaves the log consistent
+		l.segments = l.segments[1:]
+		excessBytes -= s.size
+		deleted = append(deleted, DeletedSegment{BaseOffset: s.baseOffset, NextOffset: s.nextOffset, Size: s.size, Reason: reason})
+		if err = s.delete(l.dir); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
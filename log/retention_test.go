@@ -0,0 +1,77 @@
+package log
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestApplyRetention(t *testing.T) {
+	batchSize := int64(len(valueBatch(t, 0, "value")))
+	// batch i has timestamp i*1000 and segments hold two batches, so the closed
+	// segments have largest timestamps 1000, 3000, 5000 and 7000
+	now := time.UnixMilli(10000)
+	tests := []struct {
+		name           string
+		retentionMs    int64
+		retentionBytes int64
+		wantDeleted    []int64
+		wantReason     string
+	}{
+		{name: "unlimited", retentionMs: -1, retentionBytes: -1},
+		{name: "nothing expired", retentionMs: 9000, retentionBytes: 10 * batchSize},
+		{name: "expired", retentionMs: 5500, retentionBytes: -1, wantDeleted: []int64{0, 2}, wantReason: "retention.ms"},
+		{name: "all expired", retentionMs: 0, retentionBytes: -1, wantDeleted: []int64{0, 2, 4, 6}, wantReason: "retention.ms"},
+		{name: "too large", retentionMs: -1, retentionBytes: 5 * batchSize, wantDeleted: []int64{0, 2}, wantReason: "retention.bytes"},
+		{name: "no bytes", retentionMs: -1, retentionBytes: 0, wantDeleted: []int64{0, 2, 4, 6}, wantReason: "retention.bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			config := Config{SegmentBytes: 2 * batchSize, SegmentMs: 1 << 40, IndexIntervalBytes: 1, SegmentIndexBytes: 1 << 20}
+			l := openLog(t, dir, config)
+			for i := int64(0); i < 10; i++ {
+				appendBatches(t, l, valueBatch(t, i*1000, "value"))
+			}
+
+			deleted, err := l.ApplyRetention(tt.retentionMs, tt.retentionBytes, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var deletedOffsets []int64
+			for _, d := range deleted {
+				deletedOffsets = append(deletedOffsets, d.BaseOffset)
+				if d.NextOffset != d.BaseOffset+2 || d.Size != 2*batchSize || d.Reason != tt.wantReason {
+					t.Fatalf("deleted %+v, want offsets %d to %d, %d bytes, reason %s",
+						d, d.BaseOffset, d.BaseOffset+2, 2*batchSize, tt.wantReason)
+				}
+				for _, suffix := range []string{logFileSuffix, indexFileSuffix, timeIndexFileSuffix} {
+					if _, err := os.Stat(segmentFileName(dir, d.BaseOffset, suffix)); !os.IsNotExist(err) {
+						t.Fatalf("%s of deleted segment %d still exists", suffix, d.BaseOffset)
+					}
+				}
+			}
+			if !slices.Equal(deletedOffsets, tt.wantDeleted) {
+				t.Fatalf("deleted segments %v, want %v", deletedOffsets, tt.wantDeleted)
+			}
+
+			wantStart := int64(0)
+			if len(tt.wantDeleted) > 0 {
+				wantStart = tt.wantDeleted[len(tt.wantDeleted)-1] + 2
+			}
+			if start, next := l.Offsets(); start != wantStart || next != 10 {
+				t.Fatalf("offsets %d to %d, want %d to 10", start, next, wantStart)
+			}
+			checkRead(t, l, wantStart, 10)
+			if err = l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			l = openLog(t, dir, config)
+			if start, next := l.Offsets(); start != wantStart || next != 10 {
+				t.Fatalf("offsets after reopening %d to %d, want %d to 10", start, next, wantStart)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/record"
+)
+
+const (
+	logFileSuffix       = ".log"
+	indexFileSuffix     = ".index"
+	timeIndexFileSuffix = ".timeindex"
+)
+
+// noTimestamp marks a segment that has no batches yet.
+const noTimestamp = -1
+
+// segmentFileName names a segment file after the segment's base offset.
+func segmentFileName(dir string, baseOffset int64, suffix string) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", baseOffset, suffix))
+}
+
+// segment is a single log file holding the batches from its base offset up to
+// the base offset of the next segment, with its offset and time indexes.
+type segment struct {
+	baseOffset int64
+	file       *os.File
+	index      *offsetIndex
+	timeIndex  *timeIndex
+	size       int64
+	nextOffset int64 // one past the last offset in the segment
+
+	maxTimestamp         int64
+	offsetOfMaxTimestamp int64
+	// rollingTimestamp is the max timestamp of the first batch, from which the
+	// age of the segment is measured, or noTimestamp if it is empty.
+	rollingTimestamp     int64
+	created              time.Time
+	bytesSinceIndexEntry int64
+}
+
+func createSegment(dir string, baseOffset int64, config Config) (*segment, error) {
+	file, err := os.OpenFile(segmentFileName(dir, baseOffset, logFileSuffix), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &segment{
+		baseOffset:       baseOffset,
+		file:             file,
+		nextOffset:       baseOffset,
+		maxTimestamp:     noTimestamp,
+		rollingTimestamp: noTimestamp,
+		created:          time.Now(),
+	}
+	if err = s.openIndexes(dir, config, true); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// openSegment opens an existing segment. Its indexes are rebuilt if missing or
+// corrupt, and the batches after the last index entry are read to restore the
+// state needed to append to it.
+func openSegment(dir string, baseOffset int64, config Config) (*segment, error) {
+	file, err := os.OpenFile(segmentFileName(dir, baseOffset, logFileSuffix), os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	s, err := loadSegment(dir, baseOffset, file, info.Size(), config, false)
+	if errors.Is(err, errCorruptIndex) {
+		s, err = loadSegment(dir, baseOffset, file, info.Size(), config, true)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func loadSegment(dir string, baseOffset int64, file *os.File, size int64, config Config, rebuildIndexes bool) (*segment, error) {
+	s := &segment{
+		baseOffset:       baseOffset,
+		file:             file,
+		size:             size,
+		nextOffset:       baseOffset,
+		maxTimestamp:     noTimestamp,
+		rollingTimestamp: noTimestamp,
+		created:          time.Now(),
+	}
+	if err := s.openIndexes(dir, config, rebuildIndexes); err != nil {
+		return nil, err
+	}
+	if err := s.load(config); err != nil {
+		s.closeIndexes()
+		return nil, err
+	}
+	return s, nil
+}
+
+// openIndexes opens the index files, truncating them first if reset is set.
+func (s *segment) openIndexes(dir string, config Config, reset bool) error {
+	indexPath := segmentFileName(dir, s.baseOffset, indexFileSuffix)
+	timeIndexPath := segmentFileName(dir, s.baseOffset, timeIndexFileSuffix)
+	if reset {
+		for _, path := range []string{indexPath, timeIndexPath} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	index, err := openOffsetIndex(indexPath, s.baseOffset, config.SegmentIndexBytes)
+	if err != nil {
+		return err
+	}
+	timeIndex, err := openTimeIndex(timeIndexPath, s.baseOffset, config.SegmentIndexBytes)
+	if err != nil {
+		index.file.Close()
+		return err
+	}
+	s.index, s.timeIndex = index, timeIndex
+	return nil
+}
+
+// load reads the batches that the indexes do not cover, adding the index
+// entries they are missing. Indexes pointing past the end of the segment are
+// reported as corrupt.
+func (s *segment) load(config Config) error {
+	if last, ok := s.timeIndex.last(); ok {
+		s.maxTimestamp, s.offsetOfMaxTimestamp = last.timestamp, last.offset
+	}
+	position := int64(0)
+	if last, ok := s.index.last(); ok {
+		if last.position >= s.size {
+			return errCorruptIndex
+		}
+		position = last.position
+	}
+
+	// only the first batch is needed from the start of the segment
+	err := s.scan(0, func(_ int64, header []byte) (bool, error) {
+		s.rollingTimestamp = record.BatchMaxTimestamp(header)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	err = s.scan(position, func(batchPosition int64, header []byte) (bool, error) {
+		// batches going back in offsets are left for recovery to truncate
+		if record.BatchBaseOffset(header) < s.nextOffset || record.BatchLastOffset(header) < record.BatchBaseOffset(header) {
+			return false, nil
+		}
+		if batchPosition == position && position > 0 {
+			// the batch the last index entry points at is already indexed
+			s.bytesSinceIndexEntry = int64(record.BatchSize(header))
+			s.track(header)
+			return true, nil
+		}
+		return true, s.indexBatch(batchPosition, header, config)
+	})
+	if err != nil {
+		return err
+	}
+	if last, ok := s.index.last(); ok && last.offset >= s.nextOffset {
+		return errCorruptIndex
+	}
+	if last, ok := s.timeIndex.last(); ok && last.offset >= s.nextOffset {
+		return errCorruptIndex
+	}
+	return nil
+}
+
+// indexBatch accounts for a batch appended at position, adding index entries
+// once enough bytes were appended since the last ones.
+func (s *segment) indexBatch(position int64, header []byte, config Config) error {
+	s.track(header)
+	if s.bytesSinceIndexEntry > int64(config.IndexIntervalBytes) {
+		if err := s.index.append(record.BatchLastOffset(header), position); err != nil {
+			return err
+		}
+		if err := s.timeIndex.maybeAppend(s.maxTimestamp, s.offsetOfMaxTimestamp); err != nil {
+			return err
+		}
+		s.bytesSinceIndexEntry = 0
+	}
+	s.bytesSinceIndexEntry += int64(record.BatchSize(header))
+	return nil
+}
+
+// track notes the offsets and timestamp of a batch in the segment.
+func (s *segment) track(header []byte) {
+	s.nextOffset = record.BatchLastOffset(header) + 1
+	if timestamp := record.BatchMaxTimestamp(header); timestamp > s.maxTimestamp {
+		s.maxTimestamp = timestamp
+		s.offsetOfMaxTimestamp = record.BatchLastOffset(header)
+	}
+}
+
+// append writes batches, whose offsets are already assigned, to the end of the segment.
+func (s *segment) append(batches [][]byte, config Config) error {
+	var data []byte
+	for _, batch := range batches {
+		data = append(data, batch...)
+	}
+	if _, err := s.file.WriteAt(data, s.size); err != nil {
+		return err
+	}
+	if s.rollingTimestamp == noTimestamp {
+		s.rollingTimestamp = record.BatchMaxTimestamp(batches[0])
+	}
+	position := s.size
+	s.size += int64(len(data))
+	for _, batch := range batches {
+		if err := s.indexBatch(position, batch, config); err != nil {
+			return err
+		}
+		position += int64(len(batch))
+	}
+	return nil
+}
+
+// timeWaitedForRoll returns how long the segment has been receiving batches,
+// judged by their timestamps where available.
+func (s *segment) timeWaitedForRoll(now time.Time, largestTimestamp int64) int64 {
+	if s.rollingTimestamp != noTimestamp {
+		return largestTimestamp - s.rollingTimestamp
+	}
+	return now.Sub(s.created).Milliseconds()
+}
+
+// onBecomeInactive records the segment's largest timestamp in the time index,
+// so that it can be found again after the segment is closed.
+func (s *segment) onBecomeInactive() error {
+	if s.maxTimestamp == noTimestamp {
+		return nil
+	}
+	return s.timeIndex.maybeAppend(s.maxTimestamp, s.offsetOfMaxTimestamp)
+}
+
+// largestTimestamp returns the largest record timestamp in the segment, or
+// when its records carry none, the time the segment was last written.
+func (s *segment) largestTimestamp() (int64, error) {
+	if s.maxTimestamp != noTimestamp {
+		return s.maxTimestamp, nil
+	}
+	info, err := s.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.ModTime().UnixMilli(), nil
+}
+
+// scan calls fn with the position and header of each complete batch from
+// position onwards, until fn returns false.
+func (s *segment) scan(position int64, fn func(position int64, header []byte) (bool, error)) error {
+	header := make([]byte, record.BatchHeaderLength)
+	for position+record.BatchHeaderLength <= s.size {
+		if _, err := s.file.ReadAt(header, position); err != nil {
+			return err
+		}
+		size := int64(record.BatchSize(header))
+		if size < record.BatchHeaderLength || position+size > s.size {
+			return nil
+		}
+		more, err := fn(position, header)
+		if err != nil || !more {
+			return err
+		}
+		position += size
+	}
+	return nil
+}
+
+// readBatch reads the whole batch at position, whose header was already read.
+func (s *segment) readBatch(position int64, header []byte) ([]byte, error) {
+	batch := make([]byte, record.BatchSize(header))
+	if _, err := s.file.ReadAt(batch, position); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// read returns whole batches starting with the one holding offset, stopping
+// before maxBytes would be exceeded unless minOneBatch allows the first batch
+// through. It returns nil if the segment holds nothing at or after offset.
+func (s *segment) read(offset int64, maxBytes int, minOneBatch bool) ([]byte, error) {
+	start, end := int64(-1), int64(-1)
+	err := s.scan(s.index.lookup(offset), func(position int64, header []byte) (bool, error) {
+		if start < 0 {
+			if record.BatchLastOffset(header) < offset {
+				return true, nil
+			}
+			start, end = position, position
+		}
+		size := int64(record.BatchSize(header))
+		if end-start+size > int64(maxBytes) && !(minOneBatch && end == start) {
+			return false, nil
+		}
+		end = position + size
+		return true, nil
+	})
+	if err != nil || start < 0 {
+		return nil, err
+	}
+	data := make([]byte, end-start)
+	if _, err = s.file.ReadAt(data, start); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data, nil
+}
+
+// findByTimestamp returns the first batch with a max timestamp at or after
+// timestamp, or nil if there is none.
+func (s *segment) findByTimestamp(timestamp int64) ([]byte, error) {
+	var found []byte
+	position := s.index.lookup(s.timeIndex.lookup(timestamp))
+	err := s.scan(position, func(position int64, header []byte) (bool, error) {
+		if record.BatchMaxTimestamp(header) < timestamp {
+			return true, nil
+		}
+		var err error
+		found, err = s.readBatch(position, header)
+		return false, err
+	})
+	return found, err
+}
+
+func (s *segment) sync() error {
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	if err := s.index.file.Sync(); err != nil {
+		return err
+	}
+	return s.timeIndex.file.Sync()
+}
+
+func (s *segment) close() error {
+	return errors.Join(s.closeIndexes(), s.file.Close())
+}
+
+func (s *segment) closeIndexes() error {
+	return errors.Join(s.index.file.Close(), s.timeIndex.file.Close())
+}
+
+// delete closes the segment and removes its files.
+func (s *segment) delete(dir string) error {
+	err := s.close()
+	for _, suffix := range []string{logFileSuffix, indexFileSuffix, timeIndexFileSuffix} {
+		if removeErr := os.Remove(segmentFileName(dir, s.baseOffset, suffix)); removeErr != nil && !os.IsNotExist(removeErr) {
+			err = errors.Join(err, removeErr)
+		}
+	}
+	return err
+}
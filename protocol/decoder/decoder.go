@@ -2,106 +2,328 @@ package decoder
 
 import (
 	"encoding/binary"
+	"errors"
+	"math"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInsufficientData = errors.New("decoder: insufficient data")
+	ErrMalformedVarint  = errors.New("decoder: malformed varint")
+	ErrInvalidLength    = errors.New("decoder: invalid length")
+	ErrUnexpectedNull   = errors.New("decoder: unexpected null value")
+)
+
+// BinaryDecoder reads big-endian Kafka protocol primitives. Reads never panic:
+// the first failure (such as running out of data) is recorded and returned by
+// Err, and every read after it returns a zero value.
 type BinaryDecoder struct {
 	raw    []byte
 	offset int
+	err    error
 }
 
 func (d *BinaryDecoder) Init(raw []byte) {
 	d.raw = raw
 	d.offset = 0
+	d.err = nil
 }
 
-func (d *BinaryDecoder) GetInt8() int8 {
-	value := int8(d.raw[d.offset])
-	d.offset++
+// Err returns the first error encountered while decoding, if any.
+func (d *BinaryDecoder) Err() error {
+	return d.err
+}
+
+func (d *BinaryDecoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+// next returns the following n bytes and advances past them, or nil if they
+// cannot be read.
+func (d *BinaryDecoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 {
+		d.fail(ErrInvalidLength)
+		return nil
+	}
+	if n > d.Remaining() {
+		d.fail(ErrInsufficientData)
+		return nil
+	}
+	value := d.raw[d.offset : d.offset+n]
+	d.offset += n
 	return value
 }
 
+// length validates a length read from the input against the data left.
+func (d *BinaryDecoder) length(n uint64) int {
+	if d.err != nil {
+		return 0
+	}
+	if n > uint64(d.Remaining()) {
+		d.fail(ErrInvalidLength)
+		return 0
+	}
+	return int(n)
+}
+
+func (d *BinaryDecoder) GetInt8() int8 {
+	b := d.next(1)
+	if b == nil {
+		return 0
+	}
+	return int8(b[0])
+}
+
 func (d *BinaryDecoder) GetInt16() int16 {
-	value := int16(binary.BigEndian.Uint16(d.raw[d.offset:]))
-	d.offset += 2
-	return value
+	b := d.next(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
 }
 
 func (d *BinaryDecoder) GetInt32() int32 {
-	value := int32(binary.BigEndian.Uint32(d.raw[d.offset:]))
-	d.offset += 4
-	return value
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
 }
 
 func (d *BinaryDecoder) GetInt64() int64 {
-	value := int64(binary.BigEndian.Uint64(d.raw[d.offset:]))
-	d.offset += 8
-	return value
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (d *BinaryDecoder) GetUint16() uint16 {
+	return uint16(d.GetInt16())
+}
+
+func (d *BinaryDecoder) GetUint32() uint32 {
+	return uint32(d.GetInt32())
+}
+
+func (d *BinaryDecoder) GetFloat64() float64 {
+	return math.Float64frombits(uint64(d.GetInt64()))
 }
 
 func (d *BinaryDecoder) GetStringLen() int16 {
-	value := int16(binary.BigEndian.Uint16(d.raw[d.offset:]))
-	d.offset += 2
-	return value
+	return d.GetInt16()
 }
 
 func (d *BinaryDecoder) GetString() string {
 	length := d.GetStringLen()
-	value := string(d.raw[d.offset : d.offset+int(length)])
-	d.offset += int(length)
-	return value
+	if length < 0 {
+		d.fail(ErrUnexpectedNull)
+		return ""
+	}
+	return string(d.next(int(length)))
 }
 
-func (d *BinaryDecoder) GetEmptyTaggedFieldArray() any {
-	tagsLength := d.GetInt8()
-	if tagsLength != 0 {
-		panic("expected empty tagged field array")
+func (d *BinaryDecoder) GetNullableString() *string {
+	length := d.GetStringLen()
+	if length < 0 || d.err != nil {
+		return nil
 	}
+	value := string(d.next(int(length)))
+	return &value
+}
+
+func (d *BinaryDecoder) GetBool() bool {
+	return d.GetInt8() != 0
+}
+
+// GetArrayLen returns the length of an array, or -1 for a null array.
+func (d *BinaryDecoder) GetArrayLen() int {
+	length := d.GetInt32()
+	if length == -1 {
+		return -1
+	}
+	if length < 0 {
+		d.fail(ErrInvalidLength)
+		return 0
+	}
+	// every element takes at least a byte, which bounds the allocation a caller makes
+	return d.length(uint64(length))
+}
+
+// GetEmptyTaggedFieldArray skips a tagged field section whose fields are all unknown to the caller.
+func (d *BinaryDecoder) GetEmptyTaggedFieldArray() any {
+	d.GetTaggedFields()
 	return nil
 }
 
+// GetTaggedFields reads a tagged field section, returning the raw value of each
+// field by tag, or nil if there are none.
+func (d *BinaryDecoder) GetTaggedFields() map[uint64][]byte {
+	count := d.GetUnsignedVarint()
+	if count == 0 || d.err != nil {
+		return nil
+	}
+	fields := make(map[uint64][]byte, d.length(count))
+	for i := uint64(0); i < count && d.err == nil; i++ {
+		tag := d.GetUnsignedVarint()
+		size := d.GetUnsignedVarint()
+		fields[tag] = d.next(d.length(size))
+	}
+	if d.err != nil {
+		return nil
+	}
+	return fields
+}
+
 func (d *BinaryDecoder) GetUnsignedVarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
 	value, n := binary.Uvarint(d.raw[d.offset:])
+	if n <= 0 {
+		if n == 0 {
+			d.fail(ErrInsufficientData)
+		} else {
+			d.fail(ErrMalformedVarint)
+		}
+		return 0
+	}
 	d.offset += n
 	return value
 }
 
-func (d *BinaryDecoder) GetCompactArrayLen() int {
+// compactLength reads a compact length, which is stored plus one so that zero
+// can mean null; it returns -1 for null.
+func (d *BinaryDecoder) compactLength() int {
 	value := d.GetUnsignedVarint()
-	return int(value) - 1
+	if value == 0 {
+		return -1
+	}
+	return d.length(value - 1)
+}
+
+// GetCompactArrayLen returns the length of a compact array, or -1 for a null array.
+func (d *BinaryDecoder) GetCompactArrayLen() int {
+	return d.compactLength()
 }
 
 func (d *BinaryDecoder) GetCompactString() string {
-	length := d.GetUnsignedVarint() - 1
-	value := string(d.raw[d.offset : d.offset+int(length)])
-	d.offset += int(length)
+	length := d.compactLength()
+	if length < 0 {
+		d.fail(ErrUnexpectedNull)
+		return ""
+	}
+	return string(d.next(length))
+}
+
+func (d *BinaryDecoder) GetCompactNullableString() *string {
+	length := d.compactLength()
+	if length < 0 || d.err != nil {
+		return nil
+	}
+	value := string(d.next(length))
+	return &value
+}
+
+func (d *BinaryDecoder) GetCompactBytes() []byte {
+	length := d.compactLength()
+	if length < 0 {
+		d.fail(ErrUnexpectedNull)
+		return nil
+	}
+	return d.next(length)
+}
+
+// GetCompactNullableBytes returns nil for null bytes.
+func (d *BinaryDecoder) GetCompactNullableBytes() []byte {
+	length := d.compactLength()
+	if length < 0 {
+		return nil
+	}
+	return d.next(length)
+}
+
+// GetNonNullableBytes reads bytes prefixed with an int32 length.
+func (d *BinaryDecoder) GetNonNullableBytes() []byte {
+	value := d.GetNullableBytes()
+	if value == nil {
+		d.fail(ErrUnexpectedNull)
+	}
 	return value
 }
 
+// GetNullableBytes reads bytes prefixed with an int32 length, returning nil for null bytes.
+func (d *BinaryDecoder) GetNullableBytes() []byte {
+	length := d.GetInt32()
+	if length == -1 || d.err != nil {
+		return nil
+	}
+	if length < 0 {
+		d.fail(ErrInvalidLength)
+		return nil
+	}
+	return d.next(int(length))
+}
+
+// GetRecords reads a RECORDS field: record batches prefixed with an int32 length, or nil.
+func (d *BinaryDecoder) GetRecords() []byte {
+	return d.GetNullableBytes()
+}
+
+// GetCompactRecords reads a COMPACT_RECORDS field, returning nil for null.
+func (d *BinaryDecoder) GetCompactRecords() []byte {
+	return d.GetCompactNullableBytes()
+}
+
 func (d *BinaryDecoder) GetSignedVarint() int64 {
+	if d.err != nil {
+		return 0
+	}
 	value, n := binary.Varint(d.raw[d.offset:])
+	if n <= 0 {
+		if n == 0 {
+			d.fail(ErrInsufficientData)
+		} else {
+			d.fail(ErrMalformedVarint)
+		}
+		return 0
+	}
 	d.offset += n
 	return value
 }
 
 func (d *BinaryDecoder) GetBytes(length int) []byte {
-	value := d.raw[d.offset : d.offset+length]
-	d.offset += length
-	return value
+	return d.next(length)
 }
 
 func (d *BinaryDecoder) GetUUID() uuid.UUID {
-	val, err := uuid.FromBytes(d.raw[d.offset : d.offset+16])
-	if err != nil {
-		panic(err)
+	b := d.next(16)
+	if b == nil {
+		return uuid.Nil
 	}
-	d.offset += 16
-	return val
+	return uuid.UUID(b)
 }
 
+// GetCompactInt32Array returns the elements of a compact int32 array, or nil for a null array.
 func (d *BinaryDecoder) GetCompactInt32Array() []int32 {
-	arrayLength := d.GetCompactArrayLen()
+	return d.getInt32Array(d.GetCompactArrayLen())
+}
+
+// GetInt32Array returns the elements of an int32 array, or nil for a null array.
+func (d *BinaryDecoder) GetInt32Array() []int32 {
+	return d.getInt32Array(d.GetArrayLen())
+}
+
+func (d *BinaryDecoder) getInt32Array(arrayLength int) []int32 {
+	if arrayLength < 0 || d.err != nil {
+		return nil
+	}
 	array := make([]int32, arrayLength)
 	for i := 0; i < arrayLength; i++ {
 		array[i] = d.GetInt32()
@@ -0,0 +1,115 @@
+package decoder
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDecoder(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name    string
+		raw     []byte
+		get     func(d *BinaryDecoder) any
+		want    any
+		wantErr error
+	}{
+		{name: "int32", raw: []byte{0, 0, 1, 2}, get: func(d *BinaryDecoder) any { return d.GetInt32() }, want: int32(258)},
+		{name: "int32 short", raw: []byte{0, 0, 1}, get: func(d *BinaryDecoder) any { return d.GetInt32() }, want: int32(0), wantErr: ErrInsufficientData},
+		{name: "int64 short", raw: []byte{}, get: func(d *BinaryDecoder) any { return d.GetInt64() }, want: int64(0), wantErr: ErrInsufficientData},
+		{name: "uuid short", raw: make([]byte, 15), get: func(d *BinaryDecoder) any { return d.GetUUID() }, want: uuid.Nil, wantErr: ErrInsufficientData},
+
+		{name: "string", raw: []byte{0, 2, 'h', 'i'}, get: func(d *BinaryDecoder) any { return d.GetString() }, want: "hi"},
+		{name: "string longer than the data", raw: []byte{0, 3, 'h', 'i'}, get: func(d *BinaryDecoder) any { return d.GetString() }, want: "", wantErr: ErrInsufficientData},
+		{name: "null string", raw: []byte{0xff, 0xff}, get: func(d *BinaryDecoder) any { return d.GetString() }, want: "", wantErr: ErrUnexpectedNull},
+		{name: "nullable string", raw: []byte{0, 1, 'a'}, get: func(d *BinaryDecoder) any { return d.GetNullableString() }, want: str("a")},
+		{name: "null nullable string", raw: []byte{0xff, 0xff}, get: func(d *BinaryDecoder) any { return d.GetNullableString() }, want: (*string)(nil)},
+
+		{name: "compact string", raw: []byte{3, 'h', 'i'}, get: func(d *BinaryDecoder) any { return d.GetCompactString() }, want: "hi"},
+		{name: "null compact string", raw: []byte{0}, get: func(d *BinaryDecoder) any { return d.GetCompactString() }, want: "", wantErr: ErrUnexpectedNull},
+		{name: "compact string longer than the data", raw: []byte{4, 'h', 'i'}, get: func(d *BinaryDecoder) any { return d.GetCompactString() }, want: "", wantErr: ErrInvalidLength},
+		{name: "null compact nullable string", raw: []byte{0}, get: func(d *BinaryDecoder) any { return d.GetCompactNullableString() }, want: (*string)(nil)},
+		{name: "compact nullable string", raw: []byte{2, 'a'}, get: func(d *BinaryDecoder) any { return d.GetCompactNullableString() }, want: str("a")},
+		{name: "null compact bytes", raw: []byte{0}, get: func(d *BinaryDecoder) any { return d.GetCompactBytes() }, want: []byte(nil), wantErr: ErrUnexpectedNull},
+		{name: "null compact nullable bytes", raw: []byte{0}, get: func(d *BinaryDecoder) any { return d.GetCompactNullableBytes() }, want: []byte(nil)},
+
+		{name: "bytes", raw: []byte{0, 0, 0, 1, 7}, get: func(d *BinaryDecoder) any { return d.GetNonNullableBytes() }, want: []byte{7}},
+		{name: "null bytes", raw: []byte{0xff, 0xff, 0xff, 0xff}, get: func(d *BinaryDecoder) any { return d.GetNonNullableBytes() }, want: []byte(nil), wantErr: ErrUnexpectedNull},
+		{name: "null nullable bytes", raw: []byte{0xff, 0xff, 0xff, 0xff}, get: func(d *BinaryDecoder) any { return d.GetNullableBytes() }, want: []byte(nil)},
+		{name: "negative bytes length", raw: []byte{0xff, 0xff, 0xff, 0xfe}, get: func(d *BinaryDecoder) any { return d.GetNullableBytes() }, want: []byte(nil), wantErr: ErrInvalidLength},
+
+		{name: "array length", raw: []byte{0, 0, 0, 2, 0, 0}, get: func(d *BinaryDecoder) any { return d.GetArrayLen() }, want: 2},
+		{name: "null array", raw: []byte{0xff, 0xff, 0xff, 0xff}, get: func(d *BinaryDecoder) any { return d.GetArrayLen() }, want: -1},
+		{name: "negative array length", raw: []byte{0xff, 0xff, 0xff, 0xfe}, get: func(d *BinaryDecoder) any { return d.GetArrayLen() }, want: 0, wantErr: ErrInvalidLength},
+		// a huge length must not be allocated for before the data runs out
+		{name: "array longer than the data", raw: []byte{0x7f, 0xff, 0xff, 0xff}, get: func(d *BinaryDecoder) any { return d.GetArrayLen() }, want: 0, wantErr: ErrInvalidLength},
+		{name: "compact array length", raw: []byte{3, 0, 0}, get: func(d *BinaryDecoder) any { return d.GetCompactArrayLen() }, want: 2},
+		{name: "null compact array", raw: []byte{0}, get: func(d *BinaryDecoder) any { return d.GetCompactArrayLen() }, want: -1},
+		{name: "compact array longer than the data", raw: []byte{0xff, 0xff, 0xff, 0xff, 0x0f}, get: func(d *BinaryDecoder) any { return d.GetCompactArrayLen() }, want: 0, wantErr: ErrInvalidLength},
+		{name: "int32 array", raw: []byte{0, 0, 0, 1, 0, 0, 0, 5}, get: func(d *BinaryDecoder) any { return d.GetInt32Array() }, want: []int32{5}},
+		{name: "null compact int32 array", raw: []byte{0}, get: func(d *BinaryDecoder) any { return d.GetCompactInt32Array() }, want: []int32(nil)},
+
+		{name: "unsigned varint", raw: []byte{0xac, 0x02}, get: func(d *BinaryDecoder) any { return d.GetUnsignedVarint() }, want: uint64(300)},
+		{name: "unsigned varint cut off", raw: []byte{0xac}, get: func(d *BinaryDecoder) any { return d.GetUnsignedVarint() }, want: uint64(0), wantErr: ErrInsufficientData},
+		{
+			name: "unsigned varint overflow", raw: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+			get: func(d *BinaryDecoder) any { return d.GetUnsignedVarint() }, want: uint64(0), wantErr: ErrMalformedVarint,
+		},
+		{name: "signed varint", raw: []byte{0x03}, get: func(d *BinaryDecoder) any { return d.GetSignedVarint() }, want: int64(-2)},
+		{name: "signed varint cut off", raw: []byte{0x80}, get: func(d *BinaryDecoder) any { return d.GetSignedVarint() }, want: int64(0), wantErr: ErrInsufficientData},
+
+		{name: "no tagged fields", raw: []byte{0}, get: func(d *BinaryDecoder) any { return d.GetTaggedFields() }, want: map[uint64][]byte(nil)},
+		{
+			name: "tagged fields", raw: []byte{2, 0, 1, 9, 5, 0},
+			get: func(d *BinaryDecoder) any { return d.GetTaggedFields() }, want: map[uint64][]byte{0: {9}, 5: {}},
+		},
+		{name: "tagged field longer than the data", raw: []byte{1, 0, 2, 9}, get: func(d *BinaryDecoder) any { return d.GetTaggedFields() }, want: map[uint64][]byte(nil), wantErr: ErrInvalidLength},
+		{name: "more tagged fields than data", raw: []byte{5, 0, 0}, get: func(d *BinaryDecoder) any { return d.GetTaggedFields() }, want: map[uint64][]byte(nil), wantErr: ErrInvalidLength},
+		{name: "tagged field count cut off", raw: []byte{}, get: func(d *BinaryDecoder) any { return d.GetTaggedFields() }, want: map[uint64][]byte(nil), wantErr: ErrInsufficientData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &BinaryDecoder{}
+			d.Init(tt.raw)
+			got := tt.get(d)
+			if !errors.Is(d.Err(), tt.wantErr) || (d.Err() == nil) != (tt.wantErr == nil) {
+				t.Fatalf("error %v, want %v", d.Err(), tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecoderErrorIsSticky(t *testing.T) {
+	d := &BinaryDecoder{}
+	d.Init([]byte{0, 0, 0, 1, 0, 0, 0, 1})
+	d.GetInt16()
+	d.GetInt64() // runs out of data
+	if !errors.Is(d.Err(), ErrInsufficientData) {
+		t.Fatalf("error %v, want %v", d.Err(), ErrInsufficientData)
+	}
+	// later reads return zero values and keep the first error, even if they would fit
+	if got := d.GetInt16(); got != 0 {
+		t.Fatalf("read %d after an error, want 0", got)
+	}
+	if got := d.GetUnsignedVarint(); got != 0 {
+		t.Fatalf("read %d after an error, want 0", got)
+	}
+	d.GetString()
+	if !errors.Is(d.Err(), ErrInsufficientData) {
+		t.Fatalf("error %v, want the first one, %v", d.Err(), ErrInsufficientData)
+	}
+	if d.Remaining() != 6 {
+		t.Fatalf("%d bytes left, want the 6 before the failed read", d.Remaining())
+	}
+
+	d.Init([]byte{1})
+	if d.Err() != nil || d.GetInt8() != 1 {
+		t.Fatal("Init does not reset the error")
+	}
+}
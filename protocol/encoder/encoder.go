@@ -2,55 +2,104 @@ package encoder
 
 import (
 	"encoding/binary"
+	"math"
+	"sort"
 )
 
+// BinaryEncoder writes big-endian Kafka protocol primitives, growing its buffer
+// as needed. Offsets are positions in the buffer, so they stay valid as it grows.
 type BinaryEncoder struct {
 	raw    []byte
 	offset int
+	start  int // where the encoded data begins, after any reserved size prefix
 }
 
+// Init starts encoding into raw, which is grown when it runs out of space; raw
+// may be nil.
 func (e *BinaryEncoder) Init(raw []byte) {
-	e.raw = raw
+	e.raw = raw[:cap(raw)]
 	e.offset = 0
+	e.start = 0
+}
+
+// InitResponse is like Init, but reserves room for the size prefix of a Kafka
+// response so ToKafkaResponse can fill it in instead of copying the response.
+func (e *BinaryEncoder) InitResponse(raw []byte) {
+	e.Init(raw)
+	e.grow(4)
+	e.offset = 4
+	e.start = 4
+}
+
+// grow makes room for n more bytes at the current offset.
+func (e *BinaryEncoder) grow(n int) {
+	if e.offset+n <= len(e.raw) {
+		return
+	}
+	raw := make([]byte, max(2*len(e.raw), e.offset+n, 64))
+	copy(raw, e.raw[:e.offset])
+	e.raw = raw
 }
 
 func (e *BinaryEncoder) PutRawBytes(in []byte) {
+	e.grow(len(in))
 	copy(e.raw[e.offset:], in)
 	e.offset += len(in)
 }
 
 func (e *BinaryEncoder) PutInt8(value int8) {
+	e.grow(1)
 	e.raw[e.offset] = byte(value)
 	e.offset++
 }
 
 func (e *BinaryEncoder) PutInt16(value int16) {
+	e.grow(2)
 	binary.BigEndian.PutUint16(e.raw[e.offset:], uint16(value))
 	e.offset += 2
 }
 
 func (e *BinaryEncoder) PutInt32(value int32) {
+	e.grow(4)
 	binary.BigEndian.PutUint32(e.raw[e.offset:], uint32(value))
 	e.offset += 4
 }
 
+// PutInt32At overwrites four already written bytes at offset, such as a
+// placeholder for a length that is only known afterwards.
 func (e *BinaryEncoder) PutInt32At(value int32, offset int) {
-	binary.BigEndian.PutUint32(e.raw[e.offset:], uint32(value))
+	binary.BigEndian.PutUint32(e.raw[offset:e.offset], uint32(value))
 }
 
 func (e *BinaryEncoder) PutInt64(value int64) {
+	e.grow(8)
 	binary.BigEndian.PutUint64(e.raw[e.offset:], uint64(value))
 	e.offset += 8
 }
 
+func (e *BinaryEncoder) PutUint16(value uint16) {
+	e.PutInt16(int16(value))
+}
+
+func (e *BinaryEncoder) PutUint32(value uint32) {
+	e.PutInt32(int32(value))
+}
+
+func (e *BinaryEncoder) PutFloat64(value float64) {
+	e.PutInt64(int64(math.Float64bits(value)))
+}
+
 func (e *BinaryEncoder) PutUvarint(value int64) {
+	e.grow(binary.MaxVarintLen64)
 	e.offset += binary.PutUvarint(e.raw[e.offset:], uint64(value))
 }
 
 func (e *BinaryEncoder) PutVarint(value int64) {
+	e.grow(binary.MaxVarintLen64)
 	e.offset += binary.PutVarint(e.raw[e.offset:], value)
 }
 
+// PutCompactArrayLen writes the length of a compact array; -1 writes a null array.
 func (e *BinaryEncoder) PutCompactArrayLen(len int) {
 	e.PutUvarint(int64(len + 1))
 }
@@ -59,20 +108,125 @@ func (e *BinaryEncoder) PutEmptyTaggedFieldArray() {
 	e.PutUvarint(0)
 }
 
+// PutTaggedFields writes a tagged field section holding the given raw values,
+// in ascending tag order as the protocol requires.
+func (e *BinaryEncoder) PutTaggedFields(fields map[uint64][]byte) {
+	tags := make([]uint64, 0, len(fields))
+	for tag := range fields {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+
+	e.PutUvarint(int64(len(tags)))
+	for _, tag := range tags {
+		e.PutUvarint(int64(tag))
+		e.PutUvarint(int64(len(fields[tag])))
+		e.PutRawBytes(fields[tag])
+	}
+}
+
 func (e *BinaryEncoder) PutCompactString(name string) {
 	e.PutCompactArrayLen(len(name))
 	e.PutRawBytes([]byte(name))
 }
 
+func (e *BinaryEncoder) PutString(value string) {
+	e.PutInt16(int16(len(value)))
+	e.PutRawBytes([]byte(value))
+}
+
+func (e *BinaryEncoder) PutNullableString(value *string) {
+	if value == nil {
+		e.PutInt16(-1)
+		return
+	}
+	e.PutString(*value)
+}
+
+func (e *BinaryEncoder) PutBool(value bool) {
+	if value {
+		e.PutInt8(1)
+	} else {
+		e.PutInt8(0)
+	}
+}
+
+// PutArrayLen writes the length of an array; -1 writes a null array.
+func (e *BinaryEncoder) PutArrayLen(len int) {
+	e.PutInt32(int32(len))
+}
+
+func (e *BinaryEncoder) PutInt32Array(value []int32) {
+	e.PutArrayLen(len(value))
+	for _, v := range value {
+		e.PutInt32(v)
+	}
+}
+
+func (e *BinaryEncoder) PutCompactNullableString(value *string) {
+	if value == nil {
+		e.PutUvarint(0)
+		return
+	}
+	e.PutCompactString(*value)
+}
+
+// PutBytes writes bytes prefixed with an int32 length; nil is written as empty.
+func (e *BinaryEncoder) PutBytes(value []byte) {
+	e.PutInt32(int32(len(value)))
+	e.PutRawBytes(value)
+}
+
+// PutNullableBytes writes bytes prefixed with an int32 length; nil is written as null.
+func (e *BinaryEncoder) PutNullableBytes(value []byte) {
+	if value == nil {
+		e.PutInt32(-1)
+		return
+	}
+	e.PutBytes(value)
+}
+
+// PutCompactBytes writes compact bytes; nil is written as empty.
+func (e *BinaryEncoder) PutCompactBytes(value []byte) {
+	e.PutCompactArrayLen(len(value))
+	e.PutRawBytes(value)
+}
+
+// PutCompactNullableBytes writes compact bytes; nil is written as null.
+func (e *BinaryEncoder) PutCompactNullableBytes(value []byte) {
+	if value == nil {
+		e.PutUvarint(0)
+		return
+	}
+	e.PutCompactBytes(value)
+}
+
+// PutRecords writes a RECORDS field holding encoded record batches.
+func (e *BinaryEncoder) PutRecords(value []byte) {
+	e.PutNullableBytes(value)
+}
+
+// PutCompactRecords writes a COMPACT_RECORDS field holding encoded record batches.
+func (e *BinaryEncoder) PutCompactRecords(value []byte) {
+	e.PutCompactNullableBytes(value)
+}
+
+// ToBytes returns the encoded data, without any reserved size prefix.
 func (e *BinaryEncoder) ToBytes() []byte {
-	return e.raw[:e.offset]
+	return e.raw[e.start:e.offset]
 }
 
+// ToKafkaResponse returns the encoded data prefixed with its size. It does not
+// copy the data if the encoder was set up with InitResponse.
 func (e *BinaryEncoder) ToKafkaResponse() []byte {
-	messageSize := int32(e.offset)
+	messageSize := e.offset - e.start
+	if e.start == 4 {
+		binary.BigEndian.PutUint32(e.raw, uint32(messageSize))
+		return e.raw[:e.offset]
+	}
 	res := make([]byte, 4+messageSize)
 	binary.BigEndian.PutUint32(res, uint32(messageSize))
-	copy(res[4:], e.raw[:e.offset])
+	copy(res[4:], e.raw[e.start:e.offset])
 	return res
 }
 
@@ -87,6 +241,7 @@ func (e *BinaryEncoder) Offset() int {
 	return e.offset
 }
 
+// Bytes returns everything written so far, indexed by offset.
 func (e *BinaryEncoder) Bytes() []byte {
-	return e.raw
+	return e.raw[:e.offset]
 }
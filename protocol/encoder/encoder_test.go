@@ -0,0 +1,121 @@
+package encoder
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name string
+		put  func(e *BinaryEncoder)
+		want []byte
+	}{
+		{name: "int16", put: func(e *BinaryEncoder) { e.PutInt16(-2) }, want: []byte{0xff, 0xfe}},
+		{name: "int32", put: func(e *BinaryEncoder) { e.PutInt32(258) }, want: []byte{0, 0, 1, 2}},
+		{name: "int64", put: func(e *BinaryEncoder) { e.PutInt64(1) }, want: []byte{0, 0, 0, 0, 0, 0, 0, 1}},
+		{name: "bool", put: func(e *BinaryEncoder) { e.PutBool(true); e.PutBool(false) }, want: []byte{1, 0}},
+		{name: "unsigned varint", put: func(e *BinaryEncoder) { e.PutUvarint(300) }, want: []byte{0xac, 0x02}},
+		{name: "signed varint", put: func(e *BinaryEncoder) { e.PutVarint(-2) }, want: []byte{0x03}},
+
+		{name: "string", put: func(e *BinaryEncoder) { e.PutString("hi") }, want: []byte{0, 2, 'h', 'i'}},
+		{name: "nullable string", put: func(e *BinaryEncoder) { e.PutNullableString(str("hi")) }, want: []byte{0, 2, 'h', 'i'}},
+		{name: "null string", put: func(e *BinaryEncoder) { e.PutNullableString(nil) }, want: []byte{0xff, 0xff}},
+		{name: "compact string", put: func(e *BinaryEncoder) { e.PutCompactString("hi") }, want: []byte{3, 'h', 'i'}},
+		{name: "empty compact string", put: func(e *BinaryEncoder) { e.PutCompactString("") }, want: []byte{1}},
+		{name: "compact nullable string", put: func(e *BinaryEncoder) { e.PutCompactNullableString(str("")) }, want: []byte{1}},
+		{name: "null compact string", put: func(e *BinaryEncoder) { e.PutCompactNullableString(nil) }, want: []byte{0}},
+
+		{name: "bytes", put: func(e *BinaryEncoder) { e.PutBytes([]byte{7}) }, want: []byte{0, 0, 0, 1, 7}},
+		{name: "nil bytes", put: func(e *BinaryEncoder) { e.PutBytes(nil) }, want: []byte{0, 0, 0, 0}},
+		{name: "null bytes", put: func(e *BinaryEncoder) { e.PutNullableBytes(nil) }, want: []byte{0xff, 0xff, 0xff, 0xff}},
+		{name: "empty nullable bytes", put: func(e *BinaryEncoder) { e.PutNullableBytes([]byte{}) }, want: []byte{0, 0, 0, 0}},
+		{name: "compact bytes", put: func(e *BinaryEncoder) { e.PutCompactBytes([]byte{7}) }, want: []byte{2, 7}},
+		{name: "null compact bytes", put: func(e *BinaryEncoder) { e.PutCompactNullableBytes(nil) }, want: []byte{0}},
+		{name: "null records", put: func(e *BinaryEncoder) { e.PutRecords(nil) }, want: []byte{0xff, 0xff, 0xff, 0xff}},
+		{name: "null compact records", put: func(e *BinaryEncoder) { e.PutCompactRecords(nil) }, want: []byte{0}},
+
+		{name: "array length", put: func(e *BinaryEncoder) { e.PutArrayLen(2) }, want: []byte{0, 0, 0, 2}},
+		{name: "null array", put: func(e *BinaryEncoder) { e.PutArrayLen(-1) }, want: []byte{0xff, 0xff, 0xff, 0xff}},
+		{name: "null compact array", put: func(e *BinaryEncoder) { e.PutCompactArrayLen(-1) }, want: []byte{0}},
+		{name: "int32 array", put: func(e *BinaryEncoder) { e.PutInt32Array([]int32{5}) }, want: []byte{0, 0, 0, 1, 0, 0, 0, 5}},
+		{name: "compact int32 array", put: func(e *BinaryEncoder) { e.PutCompactInt32Array([]int32{5}) }, want: []byte{2, 0, 0, 0, 5}},
+
+		{name: "empty tagged fields", put: func(e *BinaryEncoder) { e.PutEmptyTaggedFieldArray() }, want: []byte{0}},
+		{
+			name: "tagged fields in tag order",
+			put:  func(e *BinaryEncoder) { e.PutTaggedFields(map[uint64][]byte{5: {}, 0: {9}}) },
+			want: []byte{2, 0, 1, 9, 5, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &BinaryEncoder{}
+			e.Init(nil)
+			tt.put(e)
+			if got := e.Bytes(); !bytes.Equal(got, tt.want) {
+				t.Fatalf("encoded %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoderGrows(t *testing.T) {
+	e := &BinaryEncoder{}
+	e.Init(make([]byte, 0, 3))
+	var want []byte
+	for i := 0; i < 1000; i++ {
+		e.PutInt32(int32(i))
+		want = append(want, byte(i>>24), byte(i>>16), byte(i>>8), byte(i))
+	}
+	if !bytes.Equal(e.Bytes(), want) || e.Offset() != len(want) {
+		t.Fatalf("encoded %d bytes that differ from the %d written", e.Offset(), len(want))
+	}
+}
+
+// TestPutInt32At is a regression test: PutInt32At used to write at the current
+// offset rather than the one it was given.
+func TestPutInt32At(t *testing.T) {
+	e := &BinaryEncoder{}
+	e.Init(nil)
+	e.PutInt8(1)
+	lengthOffset := e.Offset()
+	e.PutInt32(0) // placeholder
+	for i := 0; i < 100; i++ {
+		e.PutInt8(2) // enough to grow the buffer after the placeholder
+	}
+	e.PutInt32At(100, lengthOffset)
+
+	want := append([]byte{1, 0, 0, 0, 100}, bytes.Repeat([]byte{2}, 100)...)
+	if !bytes.Equal(e.Bytes(), want) {
+		t.Fatalf("encoded %x, want %x", e.Bytes(), want)
+	}
+}
+
+func TestKafkaResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		init func(e *BinaryEncoder)
+	}{
+		{name: "Init", init: func(e *BinaryEncoder) { e.Init(nil) }},
+		{name: "InitResponse", init: func(e *BinaryEncoder) { e.InitResponse(nil) }},
+		{name: "InitResponse with a buffer", init: func(e *BinaryEncoder) { e.InitResponse(make([]byte, 2, 6)) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &BinaryEncoder{}
+			tt.init(e)
+			e.PutInt32(7)
+			e.PutString("abc")
+			body := []byte{0, 0, 0, 7, 0, 3, 'a', 'b', 'c'}
+			if got := e.ToBytes(); !bytes.Equal(got, body) {
+				t.Fatalf("ToBytes returned %x, want %x without a size prefix", got, body)
+			}
+			want := append([]byte{0, 0, 0, byte(len(body))}, body...)
+			if got := e.ToKafkaResponse(); !bytes.Equal(got, want) {
+				t.Fatalf("ToKafkaResponse returned %x, want %x", got, want)
+			}
+		})
+	}
+}
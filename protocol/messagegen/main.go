@@ -0,0 +1,875 @@
+// Command messagegen generates Go message types from the Kafka protocol JSON
+// schemas (clients/src/main/resources/common/message in the Kafka repository).
+//
+// Every message becomes a struct with Encode and Decode methods for each of its
+// valid versions, built on the protocol encoder and decoder. Flexible versions
+// use compact strings, arrays and bytes plus tagged fields.
+//
+// Usage:
+//
+//	go run ./protocol/messagegen -schemas <dir> -out <dir> [-package messages]
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type schema struct {
+	ApiKey           *int16          `json:"apiKey"`
+	Type             string          `json:"type"`
+	Name             string          `json:"name"`
+	ValidVersions    string          `json:"validVersions"`
+	FlexibleVersions string          `json:"flexibleVersions"`
+	Fields           []*field        `json:"fields"`
+	CommonStructs    []*commonStruct `json:"commonStructs"`
+}
+
+type commonStruct struct {
+	Name     string   `json:"name"`
+	Versions string   `json:"versions"`
+	Fields   []*field `json:"fields"`
+}
+
+type field struct {
+	Name             string          `json:"name"`
+	Type             string          `json:"type"`
+	Versions         string          `json:"versions"`
+	NullableVersions string          `json:"nullableVersions"`
+	TaggedVersions   string          `json:"taggedVersions"`
+	Tag              *int            `json:"tag"`
+	Default          json.RawMessage `json:"default"`
+	About            string          `json:"about"`
+	Fields           []*field        `json:"fields"`
+}
+
+// versions is an inclusive version range; it is empty when min > max.
+type versions struct {
+	min, max int16
+}
+
+var noVersions = versions{min: 1, max: 0}
+
+func parseVersions(s string) (versions, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "none" {
+		return noVersions, nil
+	}
+	if strings.HasSuffix(s, "+") {
+		min, err := strconv.ParseInt(strings.TrimSuffix(s, "+"), 10, 16)
+		if err != nil {
+			return noVersions, fmt.Errorf("invalid versions %q", s)
+		}
+		return versions{min: int16(min), max: math.MaxInt16}, nil
+	}
+	lo, hi, found := strings.Cut(s, "-")
+	if !found {
+		hi = lo
+	}
+	min, err := strconv.ParseInt(lo, 10, 16)
+	if err != nil {
+		return noVersions, fmt.Errorf("invalid versions %q", s)
+	}
+	max, err := strconv.ParseInt(hi, 10, 16)
+	if err != nil {
+		return noVersions, fmt.Errorf("invalid versions %q", s)
+	}
+	return versions{min: int16(min), max: int16(max)}, nil
+}
+
+func mustParseVersions(s string) versions {
+	v, err := parseVersions(s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return v
+}
+
+func (v versions) empty() bool {
+	return v.min > v.max
+}
+
+func (v versions) intersect(o versions) versions {
+	return versions{min: max(v.min, o.min), max: min(v.max, o.max)}
+}
+
+// cond returns a Go expression over `version` that is true for the versions in
+// v, assuming the code only ever runs for versions within ctx.
+func (v versions) cond(ctx versions) string {
+	r := v.intersect(ctx)
+	if r.empty() {
+		return "false"
+	}
+	lower, upper := r.min > ctx.min, r.max < ctx.max
+	switch {
+	case lower && upper && r.min == r.max:
+		return fmt.Sprintf("version == %d", r.min)
+	case lower && upper:
+		return fmt.Sprintf("version >= %d && version <= %d", r.min, r.max)
+	case lower:
+		return fmt.Sprintf("version >= %d", r.min)
+	case upper:
+		return fmt.Sprintf("version <= %d", r.max)
+	}
+	return "true"
+}
+
+var primitives = map[string]string{
+	"bool":    "bool",
+	"int8":    "int8",
+	"int16":   "int16",
+	"int32":   "int32",
+	"int64":   "int64",
+	"uint16":  "uint16",
+	"uint32":  "uint32",
+	"float64": "float64",
+	"string":  "string",
+	"bytes":   "[]byte",
+	"records": "[]byte",
+	"uuid":    "uuid.UUID",
+}
+
+// structDef is a struct type to emit, either the message itself or one of the
+// nested or common structs its fields refer to.
+type structDef struct {
+	name     string
+	about    string
+	fields   []*field
+	versions versions
+}
+
+type generator struct {
+	msg      *schema
+	valid    versions
+	flexible versions
+	common   map[string]*commonStruct
+	structs  []*structDef
+	seen     map[string]bool
+	usesUUID bool
+	out      bytes.Buffer
+}
+
+func (g *generator) p(format string, args ...any) {
+	fmt.Fprintf(&g.out, format, args...)
+	g.out.WriteByte('\n')
+}
+
+func (g *generator) structName(typ string) string {
+	if strings.HasPrefix(typ, g.msg.Name) {
+		return typ
+	}
+	return g.msg.Name + typ
+}
+
+func elemType(f *field) (string, bool) {
+	if strings.HasPrefix(f.Type, "[]") {
+		return f.Type[2:], true
+	}
+	return f.Type, false
+}
+
+func isNullable(f *field) bool {
+	return !mustParseVersions(f.NullableVersions).empty()
+}
+
+func isTagged(f *field) bool {
+	return !mustParseVersions(f.TaggedVersions).empty()
+}
+
+func (g *generator) goType(f *field) string {
+	elem, array := elemType(f)
+	prim, isPrim := primitives[elem]
+	if elem == "uuid" {
+		g.usesUUID = true
+	}
+	switch {
+	case array && isPrim:
+		return "[]" + prim
+	case array:
+		return "[]" + g.structName(elem)
+	case isPrim && elem == "string" && isNullable(f):
+		return "*string"
+	case isPrim:
+		return prim
+	case isNullable(f):
+		return "*" + g.structName(elem)
+	}
+	return g.structName(elem)
+}
+
+// collect registers the struct and, recursively, every struct its fields use.
+func (g *generator) collect(name, about string, fields []*field, vers versions) error {
+	if g.seen[name] {
+		return nil
+	}
+	g.seen[name] = true
+	g.structs = append(g.structs, &structDef{name: name, about: about, fields: fields, versions: vers})
+	for _, f := range fields {
+		elem, _ := elemType(f)
+		if _, ok := primitives[elem]; ok {
+			continue
+		}
+		fieldVersions := mustParseVersions(f.Versions).intersect(vers)
+		if len(f.Fields) > 0 {
+			if err := g.collect(g.structName(elem), f.About, f.Fields, fieldVersions); err != nil {
+				return err
+			}
+			continue
+		}
+		common, ok := g.common[elem]
+		if !ok {
+			return fmt.Errorf("%s: unknown type %q for field %s", g.msg.Name, f.Type, f.Name)
+		}
+		if err := g.collect(g.structName(elem), "", common.Fields, g.valid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *generator) structDef(typ string) *structDef {
+	for _, s := range g.structs {
+		if s.name == g.structName(typ) {
+			return s
+		}
+	}
+	return nil
+}
+
+// defaultValue returns the Go literal for a field's schema default, or "" when
+// the default is the zero value.
+func (g *generator) defaultValue(f *field) (string, error) {
+	if len(f.Default) == 0 {
+		return "", nil
+	}
+	var def string
+	if err := json.Unmarshal(f.Default, &def); err != nil {
+		def = string(f.Default)
+	}
+	if def == "null" {
+		return "", nil
+	}
+	switch f.Type {
+	case "int8", "int16", "int32", "int64":
+		v, err := strconv.ParseInt(def, 0, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s: invalid default %q for field %s", g.msg.Name, def, f.Name)
+		}
+		if v == 0 {
+			return "", nil
+		}
+		return strconv.FormatInt(v, 10), nil
+	case "uint16", "uint32":
+		v, err := strconv.ParseUint(def, 0, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s: invalid default %q for field %s", g.msg.Name, def, f.Name)
+		}
+		if v == 0 {
+			return "", nil
+		}
+		return strconv.FormatUint(v, 10), nil
+	case "float64":
+		v, err := strconv.ParseFloat(def, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s: invalid default %q for field %s", g.msg.Name, def, f.Name)
+		}
+		if v == 0 {
+			return "", nil
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case "bool":
+		if def == "true" {
+			return "true", nil
+		}
+		return "", nil
+	case "string":
+		if def == "" {
+			return "", nil
+		}
+		if isNullable(f) {
+			return "", fmt.Errorf("%s: non-null default for nullable field %s is not supported", g.msg.Name, f.Name)
+		}
+		return strconv.Quote(def), nil
+	}
+	return "", nil
+}
+
+// comparable reports whether values of the struct can be compared with ==.
+func (g *generator) comparable(s *structDef) bool {
+	for _, f := range s.fields {
+		elem, array := elemType(f)
+		if array || elem == "bytes" || elem == "records" {
+			return false
+		}
+		if _, ok := primitives[elem]; ok || isNullable(f) {
+			continue
+		}
+		if !g.comparable(g.structDef(elem)) {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultLiteral returns a composite literal of the struct holding its defaults.
+func (g *generator) defaultLiteral(s *structDef) (string, error) {
+	var values []string
+	for _, f := range s.fields {
+		elem, array := elemType(f)
+		if _, ok := primitives[elem]; !ok && !array && !isNullable(f) {
+			lit, err := g.defaultLiteral(g.structDef(elem))
+			if err != nil {
+				return "", err
+			}
+			values = append(values, f.Name+": "+lit)
+			continue
+		}
+		def, err := g.defaultValue(f)
+		if err != nil {
+			return "", err
+		}
+		if def != "" {
+			values = append(values, f.Name+": "+def)
+		}
+	}
+	return s.name + "{" + strings.Join(values, ", ") + "}", nil
+}
+
+// nonDefault returns an expression that is true when a tagged field differs
+// from its default and so has to be written.
+func (g *generator) nonDefault(f *field, x string) (string, error) {
+	elem, array := elemType(f)
+	switch {
+	case isNullable(f) && (elem == "string" || array || !isPrimitive(elem)):
+		return x + " != nil", nil
+	case array, elem == "bytes", elem == "records":
+		return "len(" + x + ") > 0", nil
+	case elem == "uuid":
+		return x + " != uuid.Nil", nil
+	case !isPrimitive(elem):
+		s := g.structDef(elem)
+		if !g.comparable(s) {
+			return "true", nil
+		}
+		lit, err := g.defaultLiteral(s)
+		if err != nil {
+			return "", err
+		}
+		return x + " != (" + lit + ")", nil
+	}
+	def, err := g.defaultValue(f)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case elem == "bool" && def == "true":
+		return "!" + x, nil
+	case elem == "bool":
+		return x, nil
+	case def != "":
+		return x + " != " + def, nil
+	case elem == "string":
+		return x + ` != ""`, nil
+	}
+	return x + " != 0", nil
+}
+
+func isPrimitive(typ string) bool {
+	_, ok := primitives[typ]
+	return ok
+}
+
+// encodePrimitive emits code writing a non-array, non-struct value.
+func (g *generator) encodePrimitive(typ, x, nullable string, pointer bool) error {
+	switch typ {
+	case "bool":
+		g.p("enc.PutBool(%s)", x)
+	case "int8":
+		g.p("enc.PutInt8(%s)", x)
+	case "int16":
+		g.p("enc.PutInt16(%s)", x)
+	case "int32":
+		g.p("enc.PutInt32(%s)", x)
+	case "int64":
+		g.p("enc.PutInt64(%s)", x)
+	case "uint16":
+		g.p("enc.PutUint16(%s)", x)
+	case "uint32":
+		g.p("enc.PutUint32(%s)", x)
+	case "float64":
+		g.p("enc.PutFloat64(%s)", x)
+	case "string":
+		if pointer {
+			g.p("putNullableString(enc, %s, flexible, %s)", x, nullable)
+		} else {
+			g.p("putString(enc, %s, flexible)", x)
+		}
+	case "bytes", "records":
+		g.p("putBytes(enc, %s, flexible, %s)", x, nullable)
+	case "uuid":
+		g.p("enc.PutRawBytes(%s[:])", x)
+	default:
+		return fmt.Errorf("%s: unknown primitive type %q", g.msg.Name, typ)
+	}
+	return nil
+}
+
+// decodePrimitive returns an expression reading a non-array, non-struct value.
+func (g *generator) decodePrimitive(typ, nullable string, pointer bool) (string, error) {
+	switch typ {
+	case "bool":
+		return "dec.GetBool()", nil
+	case "int8":
+		return "dec.GetInt8()", nil
+	case "int16":
+		return "dec.GetInt16()", nil
+	case "int32":
+		return "dec.GetInt32()", nil
+	case "int64":
+		return "dec.GetInt64()", nil
+	case "uint16":
+		return "dec.GetUint16()", nil
+	case "uint32":
+		return "dec.GetUint32()", nil
+	case "float64":
+		return "dec.GetFloat64()", nil
+	case "string":
+		if pointer {
+			return fmt.Sprintf("getNullableString(dec, flexible, %s)", nullable), nil
+		}
+		return "getString(dec, flexible)", nil
+	case "bytes", "records":
+		return fmt.Sprintf("getBytes(dec, flexible, %s)", nullable), nil
+	case "uuid":
+		return "dec.GetUUID()", nil
+	}
+	return "", fmt.Errorf("%s: unknown primitive type %q", g.msg.Name, typ)
+}
+
+func (g *generator) encodeField(f *field, x string, ctx versions) error {
+	elem, array := elemType(f)
+	nullable := mustParseVersions(f.NullableVersions).cond(ctx)
+	switch {
+	case array:
+		if isNullable(f) {
+			g.p("putNullableArrayLen(enc, %s == nil, len(%s), flexible, %s)", x, x, nullable)
+		} else {
+			g.p("putArrayLen(enc, len(%s), flexible)", x)
+		}
+		if isPrimitive(elem) {
+			g.p("for _, v := range %s {", x)
+			if err := g.encodePrimitive(elem, "v", "false", false); err != nil {
+				return err
+			}
+		} else {
+			g.p("for i := range %s {", x)
+			g.p("if err := %s[i].Encode(enc, version); err != nil {", x)
+			g.p("return err")
+			g.p("}")
+		}
+		g.p("}")
+	case isPrimitive(elem):
+		if err := g.encodePrimitive(elem, x, nullable, elem == "string" && isNullable(f)); err != nil {
+			return err
+		}
+	case isNullable(f):
+		if nullable != "true" {
+			return fmt.Errorf("%s: struct field %s must be nullable in every version it is present", g.msg.Name, f.Name)
+		}
+		g.p("if %s == nil {", x)
+		g.p("enc.PutInt8(-1)")
+		g.p("} else {")
+		g.p("enc.PutInt8(1)")
+		g.p("if err := %s.Encode(enc, version); err != nil {", x)
+		g.p("return err")
+		g.p("}")
+		g.p("}")
+	default:
+		g.p("if err := %s.Encode(enc, version); err != nil {", x)
+		g.p("return err")
+		g.p("}")
+	}
+	return nil
+}
+
+func (g *generator) decodeField(f *field, x string, ctx versions) error {
+	elem, array := elemType(f)
+	nullable := mustParseVersions(f.NullableVersions).cond(ctx)
+	switch {
+	case array:
+		g.p("if n := getArrayLen(dec, flexible); n >= 0 {")
+		g.p("%s = make(%s, n)", x, g.goType(f))
+		g.p("for i := range %s {", x)
+		if isPrimitive(elem) {
+			get, err := g.decodePrimitive(elem, "false", false)
+			if err != nil {
+				return err
+			}
+			g.p("%s[i] = %s", x, get)
+		} else {
+			g.p("if err := %s[i].Decode(dec, version); err != nil {", x)
+			g.p("return err")
+			g.p("}")
+		}
+		g.p("}")
+		g.p("}")
+	case isPrimitive(elem):
+		get, err := g.decodePrimitive(elem, nullable, elem == "string" && isNullable(f))
+		if err != nil {
+			return err
+		}
+		g.p("%s = %s", x, get)
+	case isNullable(f):
+		if nullable != "true" {
+			return fmt.Errorf("%s: struct field %s must be nullable in every version it is present", g.msg.Name, f.Name)
+		}
+		g.p("if dec.GetInt8() >= 0 {")
+		g.p("%s = &%s{}", x, g.structName(elem))
+		g.p("if err := %s.Decode(dec, version); err != nil {", x)
+		g.p("return err")
+		g.p("}")
+		g.p("}")
+	default:
+		g.p("if err := %s.Decode(dec, version); err != nil {", x)
+		g.p("return err")
+		g.p("}")
+	}
+	return nil
+}
+
+// taggedFields returns the struct's tagged fields in ascending tag order.
+func taggedFields(s *structDef) ([]*field, error) {
+	var tagged []*field
+	for _, f := range s.fields {
+		if !isTagged(f) {
+			continue
+		}
+		if f.Tag == nil {
+			return nil, fmt.Errorf("%s: tagged field %s has no tag", s.name, f.Name)
+		}
+		tagged = append(tagged, f)
+	}
+	sort.SliceStable(tagged, func(i, j int) bool { return *tagged[i].Tag < *tagged[j].Tag })
+	return tagged, nil
+}
+
+// block emits body, guarded by cond unless it always holds.
+func (g *generator) block(cond string, body func() error) error {
+	if cond == "false" {
+		return nil
+	}
+	if cond != "true" {
+		g.p("if %s {", cond)
+	}
+	if err := body(); err != nil {
+		return err
+	}
+	if cond != "true" {
+		g.p("}")
+	}
+	return nil
+}
+
+func (g *generator) genStruct(s *structDef) error {
+	if s.name == g.msg.Name {
+		g.p("// %s is the %s %s, versions %d to %d.", s.name, apiName(g.msg), g.msg.Type, g.valid.min, g.valid.max)
+	} else if s.about != "" {
+		g.p("// %s: %s", s.name, s.about)
+	}
+	g.p("type %s struct {", s.name)
+	for _, f := range s.fields {
+		if f.About != "" {
+			g.p("// %s", f.About)
+		}
+		g.p("%s %s", f.Name, g.goType(f))
+	}
+	g.p("}")
+	g.p("")
+
+	// SetDefaults
+	g.p("// SetDefaults sets every field with a non-zero default to that default.")
+	g.p("func (m *%s) SetDefaults() {", s.name)
+	for _, f := range s.fields {
+		elem, array := elemType(f)
+		if !array && !isPrimitive(elem) && !isNullable(f) {
+			g.p("m.%s.SetDefaults()", f.Name)
+			continue
+		}
+		def, err := g.defaultValue(f)
+		if err != nil {
+			return err
+		}
+		if def != "" {
+			g.p("m.%s = %s", f.Name, def)
+		}
+	}
+	g.p("}")
+	g.p("")
+
+	flexible := g.flexible.cond(s.versions)
+	taggedCtx := s.versions.intersect(g.flexible)
+	tagged, err := taggedFields(s)
+	if err != nil {
+		return err
+	}
+
+	// Encode
+	g.p("func (m *%s) Encode(enc *encoder.BinaryEncoder, version int16) error {", s.name)
+	g.p("flexible := %s", flexible)
+	for _, f := range s.fields {
+		if isTagged(f) {
+			continue
+		}
+		f := f
+		err := g.block(mustParseVersions(f.Versions).cond(s.versions), func() error {
+			return g.encodeField(f, "m."+f.Name, s.versions)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	g.p("if flexible {")
+	if len(tagged) == 0 {
+		g.p("if err := putTaggedFields(enc, nil); err != nil {")
+	} else {
+		g.p("var tagged []taggedField")
+		for _, f := range tagged {
+			fieldVersions := mustParseVersions(f.Versions).intersect(mustParseVersions(f.TaggedVersions))
+			cond := fieldVersions.cond(taggedCtx)
+			if cond == "false" {
+				continue
+			}
+			nonDefault, err := g.nonDefault(f, "m."+f.Name)
+			if err != nil {
+				return err
+			}
+			if cond == "true" {
+				cond = nonDefault
+			} else if nonDefault != "true" {
+				cond = "(" + cond + ") && " + nonDefault
+			}
+			g.p("if %s {", cond)
+			g.p("tagged = append(tagged, taggedField{tag: %d, encode: func(enc *encoder.BinaryEncoder) error {", *f.Tag)
+			if err := g.encodeField(f, "m."+f.Name, fieldVersions.intersect(taggedCtx)); err != nil {
+				return err
+			}
+			g.p("return nil")
+			g.p("}})")
+			g.p("}")
+		}
+		g.p("if err := putTaggedFields(enc, tagged); err != nil {")
+	}
+	g.p("return err")
+	g.p("}")
+	g.p("}")
+	g.p("return nil")
+	g.p("}")
+	g.p("")
+
+	// Decode
+	g.p("func (m *%s) Decode(dec *decoder.BinaryDecoder, version int16) error {", s.name)
+	g.p("*m = %s{}", s.name)
+	g.p("m.SetDefaults()")
+	g.p("flexible := %s", flexible)
+	for _, f := range s.fields {
+		if isTagged(f) {
+			continue
+		}
+		f := f
+		err := g.block(mustParseVersions(f.Versions).cond(s.versions), func() error {
+			return g.decodeField(f, "m."+f.Name, s.versions)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	g.p("if flexible {")
+	if len(tagged) == 0 {
+		g.p("if err := getTaggedFields(dec, nil); err != nil {")
+	} else {
+		g.p("err := getTaggedFields(dec, func(tag uint64, dec *decoder.BinaryDecoder) error {")
+		g.p("switch tag {")
+		for _, f := range tagged {
+			fieldVersions := mustParseVersions(f.Versions).intersect(mustParseVersions(f.TaggedVersions))
+			f := f
+			g.p("case %d:", *f.Tag)
+			err := g.block(fieldVersions.cond(taggedCtx), func() error {
+				return g.decodeField(f, "m."+f.Name, fieldVersions.intersect(taggedCtx))
+			})
+			if err != nil {
+				return err
+			}
+		}
+		g.p("}")
+		g.p("return nil")
+		g.p("})")
+		g.p("if err != nil {")
+	}
+	g.p("return err")
+	g.p("}")
+	g.p("}")
+	g.p("return dec.Err()")
+	g.p("}")
+	g.p("")
+	return nil
+}
+
+// apiName turns "FetchRequest" into "Fetch".
+func apiName(s *schema) string {
+	return strings.TrimSuffix(strings.TrimSuffix(s.Name, "Request"), "Response")
+}
+
+func (g *generator) generate(source, pkg string) ([]byte, error) {
+	for _, s := range g.msg.CommonStructs {
+		g.common[s.Name] = s
+	}
+	if err := g.collect(g.msg.Name, "", g.msg.Fields, g.valid); err != nil {
+		return nil, err
+	}
+
+	if g.msg.ApiKey != nil {
+		g.p("const (")
+		g.p("%sApiKey int16 = %d", g.msg.Name, *g.msg.ApiKey)
+		g.p("%sMinVersion int16 = %d", g.msg.Name, g.valid.min)
+		g.p("%sMaxVersion int16 = %d", g.msg.Name, g.valid.max)
+		g.p("// %sFlexibleVersion is the first version using the flexible encoding, or -1 if none does.", g.msg.Name)
+		if g.flexible.empty() {
+			g.p("%sFlexibleVersion int16 = -1", g.msg.Name)
+		} else {
+			g.p("%sFlexibleVersion int16 = %d", g.msg.Name, g.flexible.min)
+		}
+		g.p(")")
+		g.p("")
+	}
+	for _, s := range g.structs {
+		if err := g.genStruct(s); err != nil {
+			return nil, err
+		}
+	}
+	code := g.out.Bytes()
+
+	g.out = bytes.Buffer{}
+	g.p("// Code generated by messagegen from %s. DO NOT EDIT.", source)
+	g.p("")
+	g.p("package %s", pkg)
+	g.p("")
+	g.p("import (")
+	if g.usesUUID {
+		g.p(`"github.com/google/uuid"`)
+		g.p("")
+	}
+	g.p(`"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"`)
+	g.p(`"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"`)
+	g.p(")")
+	g.p("")
+	g.out.Write(code)
+
+	formatted, err := format.Source(g.out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s: formatting generated code: %w", g.msg.Name, err)
+	}
+	return formatted, nil
+}
+
+// readSchema parses a schema file, dropping the // comment lines Kafka's
+// schemas contain, which are not valid JSON.
+func readSchema(path string) (*schema, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var stripped bytes.Buffer
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "//") {
+			continue
+		}
+		stripped.WriteString(line)
+		stripped.WriteByte('\n')
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	s := &schema{}
+	if err = json.Unmarshal(stripped.Bytes(), s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// fileName turns "ApiVersionsRequest" into "api_versions_request.go".
+func fileName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToLower(b.String()) + ".go"
+}
+
+// generateFile generates the Go code for the schema at path, returning it with
+// the name of the file to write it to.
+func generateFile(path, pkg string) (string, []byte, error) {
+	msg, err := readSchema(path)
+	if err != nil {
+		return "", nil, err
+	}
+	valid, err := parseVersions(msg.ValidVersions)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", path, err)
+	}
+	flexible, err := parseVersions(msg.FlexibleVersions)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", path, err)
+	}
+	g := &generator{
+		msg:      msg,
+		valid:    valid,
+		flexible: flexible,
+		common:   make(map[string]*commonStruct),
+		seen:     make(map[string]bool),
+	}
+	code, err := g.generate(filepath.Base(path), pkg)
+	if err != nil {
+		return "", nil, err
+	}
+	return fileName(msg.Name), code, nil
+}
+
+func main() {
+	schemaDir := flag.String("schemas", "schema", "directory holding the Kafka message JSON schemas")
+	outDir := flag.String("out", ".", "directory to write the generated Go files to")
+	pkg := flag.String("package", "messages", "package name of the generated files")
+	flag.Parse()
+
+	paths, err := filepath.Glob(filepath.Join(*schemaDir, "*.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, path := range paths {
+		name, code, err := generateFile(path, *pkg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = os.WriteFile(filepath.Join(*outDir, name), code, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
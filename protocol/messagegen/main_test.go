@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseVersions(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    versions
+		wantErr bool
+	}{
+		{in: "", want: noVersions},
+		{in: "none", want: noVersions},
+		{in: "3", want: versions{min: 3, max: 3}},
+		{in: "0-12", want: versions{min: 0, max: 12}},
+		{in: "9+", want: versions{min: 9, max: math.MaxInt16}},
+		{in: " 1-2 ", want: versions{min: 1, max: 2}},
+		{in: "x+", wantErr: true},
+		{in: "1-x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseVersions(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parseVersions(%q) error %v, want one: %v", tt.in, err, tt.wantErr)
+		}
+		if err == nil && got != tt.want {
+			t.Fatalf("parseVersions(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestVersionsCond(t *testing.T) {
+	ctx := versions{min: 0, max: 12}
+	tests := []struct {
+		v    string
+		want string
+	}{
+		{v: "0+", want: "true"},
+		{v: "none", want: "false"},
+		{v: "13+", want: "false"},
+		{v: "4+", want: "version >= 4"},
+		{v: "0-10", want: "version <= 10"},
+		{v: "8-10", want: "version >= 8 && version <= 10"},
+		{v: "7", want: "version == 7"},
+	}
+	for _, tt := range tests {
+		if got := mustParseVersions(tt.v).cond(ctx); got != tt.want {
+			t.Fatalf("cond of %q = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+const testSchema = `// Kafka schemas start with a license comment.
+{
+  "apiKey": 1000,
+  "type": "request",
+  "name": "TestRequest",
+  "validVersions": "0-2",
+  "flexibleVersions": "2+",
+  "fields": [
+    { "name": "Name", "type": "string", "versions": "0+", "nullableVersions": "1+", "about": "The name." },
+    { "name": "Ids", "type": "[]int32", "versions": "0+", "nullableVersions": "2+", "about": "The ids." },
+    { "name": "Leader", "type": "int32", "versions": "2+", "taggedVersions": "2+", "tag": 0, "default": "-1", "about": "The leader." }
+  ]
+}
+`
+
+func TestGenerate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "TestRequest.json")
+	if err := os.WriteFile(path, []byte(testSchema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	name, code, err := generateFile(path, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "test_request.go" {
+		t.Fatalf("file name %q, want test_request.go", name)
+	}
+	for _, want := range []string{
+		"TestRequestApiKey     int16 = 1000",
+		"TestRequestFlexibleVersion int16 = 2",
+		"flexible := version >= 2",
+		// nullable fields
+		"putNullableString(enc, m.Name, flexible, version >= 1)",
+		"putNullableArrayLen(enc, m.Ids == nil, len(m.Ids), flexible, version >= 2)",
+		// tagged fields are only written when they differ from their default
+		"m.Leader = -1",
+		"if m.Leader != -1 {",
+		"taggedField{tag: 0,",
+		"case 0:\n\t\t\t\tm.Leader = dec.GetInt32()",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Fatalf("generated code does not contain %q:\n%s", want, code)
+		}
+	}
+}
+
+// TestGeneratedUpToDate checks the checked-in message types match their schemas.
+func TestGeneratedUpToDate(t *testing.T) {
+	paths, err := filepath.Glob("../messages/schema/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no schemas")
+	}
+	for _, path := range paths {
+		name, code, err := generateFile(path, "messages")
+		if err != nil {
+			t.Fatal(err)
+		}
+		existing, err := os.ReadFile(filepath.Join("../messages", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(code, existing) {
+			t.Fatalf("%s is out of date with %s; run go generate", name, path)
+		}
+	}
+}
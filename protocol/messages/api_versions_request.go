@@ -0,0 +1,62 @@
+// Code generated by messagegen from ApiVersionsRequest.json. DO NOT EDIT.
+
+package messages
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+const (
+	ApiVersionsRequestApiKey     int16 = 18
+	ApiVersionsRequestMinVersion int16 = 0
+	ApiVersionsRequestMaxVersion int16 = 4
+	// ApiVersionsRequestFlexibleVersion is the first version using the flexible encoding, or -1 if none does.
+	ApiVersionsRequestFlexibleVersion int16 = 3
+)
+
+// ApiVersionsRequest is the ApiVersions request, versions 0 to 4.
+type ApiVersionsRequest struct {
+	// The name of the client.
+	ClientSoftwareName string
+	// The version of the client.
+	ClientSoftwareVersion string
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ApiVersionsRequest) SetDefaults() {
+}
+
+func (m *ApiVersionsRequest) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 3
+	if version >= 3 {
+		putString(enc, m.ClientSoftwareName, flexible)
+	}
+	if version >= 3 {
+		putString(enc, m.ClientSoftwareVersion, flexible)
+	}
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ApiVersionsRequest) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ApiVersionsRequest{}
+	m.SetDefaults()
+	flexible := version >= 3
+	if version >= 3 {
+		m.ClientSoftwareName = getString(dec, flexible)
+	}
+	if version >= 3 {
+		m.ClientSoftwareVersion = getString(dec, flexible)
+	}
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
@@ -0,0 +1,271 @@
+// Code generated by messagegen from ApiVersionsResponse.json. DO NOT EDIT.
+
+package messages
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+const (
+	ApiVersionsResponseApiKey     int16 = 18
+	ApiVersionsResponseMinVersion int16 = 0
+	ApiVersionsResponseMaxVersion int16 = 4
+	// ApiVersionsResponseFlexibleVersion is the first version using the flexible encoding, or -1 if none does.
+	ApiVersionsResponseFlexibleVersion int16 = 3
+)
+
+// ApiVersionsResponse is the ApiVersions response, versions 0 to 4.
+type ApiVersionsResponse struct {
+	// The top-level error code.
+	ErrorCode int16
+	// The APIs supported by the broker.
+	ApiKeys []ApiVersionsResponseApiVersion
+	// The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+	ThrottleTimeMs int32
+	// Features supported by the broker. Note: in v0-v3, features with MinSupportedVersion = 0 are omitted.
+	SupportedFeatures []ApiVersionsResponseSupportedFeatureKey
+	// The monotonically increasing epoch for the finalized features information. Valid values are >= 0. A value of -1 is special and represents unknown epoch.
+	FinalizedFeaturesEpoch int64
+	// List of cluster-wide finalized features. The information is valid only if FinalizedFeaturesEpoch >= 0.
+	FinalizedFeatures []ApiVersionsResponseFinalizedFeatureKey
+	// Set by a KRaft controller if the required configurations for ZK migration are present.
+	ZkMigrationReady bool
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ApiVersionsResponse) SetDefaults() {
+	m.FinalizedFeaturesEpoch = -1
+}
+
+func (m *ApiVersionsResponse) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 3
+	enc.PutInt16(m.ErrorCode)
+	putArrayLen(enc, len(m.ApiKeys), flexible)
+	for i := range m.ApiKeys {
+		if err := m.ApiKeys[i].Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	if version >= 1 {
+		enc.PutInt32(m.ThrottleTimeMs)
+	}
+	if flexible {
+		var tagged []taggedField
+		if len(m.SupportedFeatures) > 0 {
+			tagged = append(tagged, taggedField{tag: 0, encode: func(enc *encoder.BinaryEncoder) error {
+				putArrayLen(enc, len(m.SupportedFeatures), flexible)
+				for i := range m.SupportedFeatures {
+					if err := m.SupportedFeatures[i].Encode(enc, version); err != nil {
+						return err
+					}
+				}
+				return nil
+			}})
+		}
+		if m.FinalizedFeaturesEpoch != -1 {
+			tagged = append(tagged, taggedField{tag: 1, encode: func(enc *encoder.BinaryEncoder) error {
+				enc.PutInt64(m.FinalizedFeaturesEpoch)
+				return nil
+			}})
+		}
+		if len(m.FinalizedFeatures) > 0 {
+			tagged = append(tagged, taggedField{tag: 2, encode: func(enc *encoder.BinaryEncoder) error {
+				putArrayLen(enc, len(m.FinalizedFeatures), flexible)
+				for i := range m.FinalizedFeatures {
+					if err := m.FinalizedFeatures[i].Encode(enc, version); err != nil {
+						return err
+					}
+				}
+				return nil
+			}})
+		}
+		if m.ZkMigrationReady {
+			tagged = append(tagged, taggedField{tag: 3, encode: func(enc *encoder.BinaryEncoder) error {
+				enc.PutBool(m.ZkMigrationReady)
+				return nil
+			}})
+		}
+		if err := putTaggedFields(enc, tagged); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ApiVersionsResponse) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ApiVersionsResponse{}
+	m.SetDefaults()
+	flexible := version >= 3
+	m.ErrorCode = dec.GetInt16()
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.ApiKeys = make([]ApiVersionsResponseApiVersion, n)
+		for i := range m.ApiKeys {
+			if err := m.ApiKeys[i].Decode(dec, version); err != nil {
+				return err
+			}
+		}
+	}
+	if version >= 1 {
+		m.ThrottleTimeMs = dec.GetInt32()
+	}
+	if flexible {
+		err := getTaggedFields(dec, func(tag uint64, dec *decoder.BinaryDecoder) error {
+			switch tag {
+			case 0:
+				if n := getArrayLen(dec, flexible); n >= 0 {
+					m.SupportedFeatures = make([]ApiVersionsResponseSupportedFeatureKey, n)
+					for i := range m.SupportedFeatures {
+						if err := m.SupportedFeatures[i].Decode(dec, version); err != nil {
+							return err
+						}
+					}
+				}
+			case 1:
+				m.FinalizedFeaturesEpoch = dec.GetInt64()
+			case 2:
+				if n := getArrayLen(dec, flexible); n >= 0 {
+					m.FinalizedFeatures = make([]ApiVersionsResponseFinalizedFeatureKey, n)
+					for i := range m.FinalizedFeatures {
+						if err := m.FinalizedFeatures[i].Decode(dec, version); err != nil {
+							return err
+						}
+					}
+				}
+			case 3:
+				m.ZkMigrationReady = dec.GetBool()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// ApiVersionsResponseApiVersion: The APIs supported by the broker.
+type ApiVersionsResponseApiVersion struct {
+	// The API index.
+	ApiKey int16
+	// The minimum supported version, inclusive.
+	MinVersion int16
+	// The maximum supported version, inclusive.
+	MaxVersion int16
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ApiVersionsResponseApiVersion) SetDefaults() {
+}
+
+func (m *ApiVersionsResponseApiVersion) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 3
+	enc.PutInt16(m.ApiKey)
+	enc.PutInt16(m.MinVersion)
+	enc.PutInt16(m.MaxVersion)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ApiVersionsResponseApiVersion) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ApiVersionsResponseApiVersion{}
+	m.SetDefaults()
+	flexible := version >= 3
+	m.ApiKey = dec.GetInt16()
+	m.MinVersion = dec.GetInt16()
+	m.MaxVersion = dec.GetInt16()
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// ApiVersionsResponseSupportedFeatureKey: Features supported by the broker. Note: in v0-v3, features with MinSupportedVersion = 0 are omitted.
+type ApiVersionsResponseSupportedFeatureKey struct {
+	// The name of the feature.
+	Name string
+	// The minimum supported version for the feature.
+	MinVersion int16
+	// The maximum supported version for the feature.
+	MaxVersion int16
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ApiVersionsResponseSupportedFeatureKey) SetDefaults() {
+}
+
+func (m *ApiVersionsResponseSupportedFeatureKey) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	putString(enc, m.Name, flexible)
+	enc.PutInt16(m.MinVersion)
+	enc.PutInt16(m.MaxVersion)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ApiVersionsResponseSupportedFeatureKey) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ApiVersionsResponseSupportedFeatureKey{}
+	m.SetDefaults()
+	flexible := true
+	m.Name = getString(dec, flexible)
+	m.MinVersion = dec.GetInt16()
+	m.MaxVersion = dec.GetInt16()
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// ApiVersionsResponseFinalizedFeatureKey: List of cluster-wide finalized features. The information is valid only if FinalizedFeaturesEpoch >= 0.
+type ApiVersionsResponseFinalizedFeatureKey struct {
+	// The name of the feature.
+	Name string
+	// The cluster-wide finalized max version level for the feature.
+	MaxVersionLevel int16
+	// The cluster-wide finalized min version level for the feature.
+	MinVersionLevel int16
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ApiVersionsResponseFinalizedFeatureKey) SetDefaults() {
+}
+
+func (m *ApiVersionsResponseFinalizedFeatureKey) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	putString(enc, m.Name, flexible)
+	enc.PutInt16(m.MaxVersionLevel)
+	enc.PutInt16(m.MinVersionLevel)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ApiVersionsResponseFinalizedFeatureKey) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ApiVersionsResponseFinalizedFeatureKey{}
+	m.SetDefaults()
+	flexible := true
+	m.Name = getString(dec, flexible)
+	m.MaxVersionLevel = dec.GetInt16()
+	m.MinVersionLevel = dec.GetInt16()
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
@@ -0,0 +1,155 @@
+// Code generated by messagegen from DescribeTopicPartitionsRequest.json. DO NOT EDIT.
+
+package messages
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+const (
+	DescribeTopicPartitionsRequestApiKey     int16 = 75
+	DescribeTopicPartitionsRequestMinVersion int16 = 0
+	DescribeTopicPartitionsRequestMaxVersion int16 = 0
+	// DescribeTopicPartitionsRequestFlexibleVersion is the first version using the flexible encoding, or -1 if none does.
+	DescribeTopicPartitionsRequestFlexibleVersion int16 = 0
+)
+
+// DescribeTopicPartitionsRequest is the DescribeTopicPartitions request, versions 0 to 0.
+type DescribeTopicPartitionsRequest struct {
+	// The topics to fetch details for.
+	Topics []DescribeTopicPartitionsRequestTopicRequest
+	// The maximum number of partitions included in the response.
+	ResponsePartitionLimit int32
+	// The first topic and partition index to fetch details for.
+	Cursor *DescribeTopicPartitionsRequestCursor
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *DescribeTopicPartitionsRequest) SetDefaults() {
+	m.ResponsePartitionLimit = 2000
+}
+
+func (m *DescribeTopicPartitionsRequest) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	putArrayLen(enc, len(m.Topics), flexible)
+	for i := range m.Topics {
+		if err := m.Topics[i].Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	enc.PutInt32(m.ResponsePartitionLimit)
+	if m.Cursor == nil {
+		enc.PutInt8(-1)
+	} else {
+		enc.PutInt8(1)
+		if err := m.Cursor.Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *DescribeTopicPartitionsRequest) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = DescribeTopicPartitionsRequest{}
+	m.SetDefaults()
+	flexible := true
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.Topics = make([]DescribeTopicPartitionsRequestTopicRequest, n)
+		for i := range m.Topics {
+			if err := m.Topics[i].Decode(dec, version); err != nil {
+				return err
+			}
+		}
+	}
+	m.ResponsePartitionLimit = dec.GetInt32()
+	if dec.GetInt8() >= 0 {
+		m.Cursor = &DescribeTopicPartitionsRequestCursor{}
+		if err := m.Cursor.Decode(dec, version); err != nil {
+			return err
+		}
+	}
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// DescribeTopicPartitionsRequestTopicRequest: The topics to fetch details for.
+type DescribeTopicPartitionsRequestTopicRequest struct {
+	// The topic name.
+	Name string
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *DescribeTopicPartitionsRequestTopicRequest) SetDefaults() {
+}
+
+func (m *DescribeTopicPartitionsRequestTopicRequest) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	putString(enc, m.Name, flexible)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *DescribeTopicPartitionsRequestTopicRequest) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = DescribeTopicPartitionsRequestTopicRequest{}
+	m.SetDefaults()
+	flexible := true
+	m.Name = getString(dec, flexible)
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// DescribeTopicPartitionsRequestCursor: The first topic and partition index to fetch details for.
+type DescribeTopicPartitionsRequestCursor struct {
+	// The name for the first topic to process.
+	TopicName string
+	// The partition index to start with.
+	PartitionIndex int32
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *DescribeTopicPartitionsRequestCursor) SetDefaults() {
+}
+
+func (m *DescribeTopicPartitionsRequestCursor) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	putString(enc, m.TopicName, flexible)
+	enc.PutInt32(m.PartitionIndex)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *DescribeTopicPartitionsRequestCursor) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = DescribeTopicPartitionsRequestCursor{}
+	m.SetDefaults()
+	flexible := true
+	m.TopicName = getString(dec, flexible)
+	m.PartitionIndex = dec.GetInt32()
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
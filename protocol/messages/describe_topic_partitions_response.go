@@ -0,0 +1,296 @@
+// Code generated by messagegen from DescribeTopicPartitionsResponse.json. DO NOT EDIT.
+
+package messages
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+const (
+	DescribeTopicPartitionsResponseApiKey     int16 = 75
+	DescribeTopicPartitionsResponseMinVersion int16 = 0
+	DescribeTopicPartitionsResponseMaxVersion int16 = 0
+	// DescribeTopicPartitionsResponseFlexibleVersion is the first version using the flexible encoding, or -1 if none does.
+	DescribeTopicPartitionsResponseFlexibleVersion int16 = 0
+)
+
+// DescribeTopicPartitionsResponse is the DescribeTopicPartitions response, versions 0 to 0.
+type DescribeTopicPartitionsResponse struct {
+	// The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+	ThrottleTimeMs int32
+	// Each topic in the response.
+	Topics []DescribeTopicPartitionsResponseTopic
+	// The next topic and partition index to fetch details for.
+	NextCursor *DescribeTopicPartitionsResponseCursor
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *DescribeTopicPartitionsResponse) SetDefaults() {
+}
+
+func (m *DescribeTopicPartitionsResponse) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	enc.PutInt32(m.ThrottleTimeMs)
+	putArrayLen(enc, len(m.Topics), flexible)
+	for i := range m.Topics {
+		if err := m.Topics[i].Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	if m.NextCursor == nil {
+		enc.PutInt8(-1)
+	} else {
+		enc.PutInt8(1)
+		if err := m.NextCursor.Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *DescribeTopicPartitionsResponse) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = DescribeTopicPartitionsResponse{}
+	m.SetDefaults()
+	flexible := true
+	m.ThrottleTimeMs = dec.GetInt32()
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.Topics = make([]DescribeTopicPartitionsResponseTopic, n)
+		for i := range m.Topics {
+			if err := m.Topics[i].Decode(dec, version); err != nil {
+				return err
+			}
+		}
+	}
+	if dec.GetInt8() >= 0 {
+		m.NextCursor = &DescribeTopicPartitionsResponseCursor{}
+		if err := m.NextCursor.Decode(dec, version); err != nil {
+			return err
+		}
+	}
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// DescribeTopicPartitionsResponseTopic: Each topic in the response.
+type DescribeTopicPartitionsResponseTopic struct {
+	// The topic error, or 0 if there was no error.
+	ErrorCode int16
+	// The topic name.
+	Name *string
+	// The topic id.
+	TopicId uuid.UUID
+	// True if the topic is internal.
+	IsInternal bool
+	// Each partition in the topic.
+	Partitions []DescribeTopicPartitionsResponsePartition
+	// 32-bit bitfield to represent authorized operations for this topic.
+	TopicAuthorizedOperations int32
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *DescribeTopicPartitionsResponseTopic) SetDefaults() {
+	m.TopicAuthorizedOperations = -2147483648
+}
+
+func (m *DescribeTopicPartitionsResponseTopic) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	enc.PutInt16(m.ErrorCode)
+	putNullableString(enc, m.Name, flexible, true)
+	enc.PutRawBytes(m.TopicId[:])
+	enc.PutBool(m.IsInternal)
+	putArrayLen(enc, len(m.Partitions), flexible)
+	for i := range m.Partitions {
+		if err := m.Partitions[i].Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	enc.PutInt32(m.TopicAuthorizedOperations)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *DescribeTopicPartitionsResponseTopic) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = DescribeTopicPartitionsResponseTopic{}
+	m.SetDefaults()
+	flexible := true
+	m.ErrorCode = dec.GetInt16()
+	m.Name = getNullableString(dec, flexible, true)
+	m.TopicId = dec.GetUUID()
+	m.IsInternal = dec.GetBool()
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.Partitions = make([]DescribeTopicPartitionsResponsePartition, n)
+		for i := range m.Partitions {
+			if err := m.Partitions[i].Decode(dec, version); err != nil {
+				return err
+			}
+		}
+	}
+	m.TopicAuthorizedOperations = dec.GetInt32()
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// DescribeTopicPartitionsResponsePartition: Each partition in the topic.
+type DescribeTopicPartitionsResponsePartition struct {
+	// The partition error, or 0 if there was no error.
+	ErrorCode int16
+	// The partition index.
+	PartitionIndex int32
+	// The ID of the leader broker.
+	LeaderId int32
+	// The leader epoch of this partition.
+	LeaderEpoch int32
+	// The set of all nodes that host this partition.
+	ReplicaNodes []int32
+	// The set of nodes that are in sync with the leader for this partition.
+	IsrNodes []int32
+	// The new eligible leader replicas otherwise.
+	EligibleLeaderReplicas []int32
+	// The last known ELR.
+	LastKnownElr []int32
+	// The set of offline replicas of this partition.
+	OfflineReplicas []int32
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *DescribeTopicPartitionsResponsePartition) SetDefaults() {
+	m.LeaderEpoch = -1
+}
+
+func (m *DescribeTopicPartitionsResponsePartition) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	enc.PutInt16(m.ErrorCode)
+	enc.PutInt32(m.PartitionIndex)
+	enc.PutInt32(m.LeaderId)
+	enc.PutInt32(m.LeaderEpoch)
+	putArrayLen(enc, len(m.ReplicaNodes), flexible)
+	for _, v := range m.ReplicaNodes {
+		enc.PutInt32(v)
+	}
+	putArrayLen(enc, len(m.IsrNodes), flexible)
+	for _, v := range m.IsrNodes {
+		enc.PutInt32(v)
+	}
+	putNullableArrayLen(enc, m.EligibleLeaderReplicas == nil, len(m.EligibleLeaderReplicas), flexible, true)
+	for _, v := range m.EligibleLeaderReplicas {
+		enc.PutInt32(v)
+	}
+	putNullableArrayLen(enc, m.LastKnownElr == nil, len(m.LastKnownElr), flexible, true)
+	for _, v := range m.LastKnownElr {
+		enc.PutInt32(v)
+	}
+	putArrayLen(enc, len(m.OfflineReplicas), flexible)
+	for _, v := range m.OfflineReplicas {
+		enc.PutInt32(v)
+	}
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *DescribeTopicPartitionsResponsePartition) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = DescribeTopicPartitionsResponsePartition{}
+	m.SetDefaults()
+	flexible := true
+	m.ErrorCode = dec.GetInt16()
+	m.PartitionIndex = dec.GetInt32()
+	m.LeaderId = dec.GetInt32()
+	m.LeaderEpoch = dec.GetInt32()
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.ReplicaNodes = make([]int32, n)
+		for i := range m.ReplicaNodes {
+			m.ReplicaNodes[i] = dec.GetInt32()
+		}
+	}
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.IsrNodes = make([]int32, n)
+		for i := range m.IsrNodes {
+			m.IsrNodes[i] = dec.GetInt32()
+		}
+	}
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.EligibleLeaderReplicas = make([]int32, n)
+		for i := range m.EligibleLeaderReplicas {
+			m.EligibleLeaderReplicas[i] = dec.GetInt32()
+		}
+	}
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.LastKnownElr = make([]int32, n)
+		for i := range m.LastKnownElr {
+			m.LastKnownElr[i] = dec.GetInt32()
+		}
+	}
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.OfflineReplicas = make([]int32, n)
+		for i := range m.OfflineReplicas {
+			m.OfflineReplicas[i] = dec.GetInt32()
+		}
+	}
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// DescribeTopicPartitionsResponseCursor: The next topic and partition index to fetch details for.
+type DescribeTopicPartitionsResponseCursor struct {
+	// The name for the first topic to process.
+	TopicName string
+	// The partition index to start with.
+	PartitionIndex int32
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *DescribeTopicPartitionsResponseCursor) SetDefaults() {
+}
+
+func (m *DescribeTopicPartitionsResponseCursor) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	putString(enc, m.TopicName, flexible)
+	enc.PutInt32(m.PartitionIndex)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *DescribeTopicPartitionsResponseCursor) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = DescribeTopicPartitionsResponseCursor{}
+	m.SetDefaults()
+	flexible := true
+	m.TopicName = getString(dec, flexible)
+	m.PartitionIndex = dec.GetInt32()
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
@@ -0,0 +1,139 @@
+package messages
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+// Helpers shared by the generated message code for fields whose wire format
+// depends on whether the API version uses the flexible (compact, tagged) encoding.
+
+func getArrayLen(dec *decoder.BinaryDecoder, flexible bool) int {
+	if flexible {
+		return dec.GetCompactArrayLen()
+	}
+	return dec.GetArrayLen()
+}
+
+func getString(dec *decoder.BinaryDecoder, flexible bool) string {
+	if flexible {
+		return dec.GetCompactString()
+	}
+	return dec.GetString()
+}
+
+// getNullableString reads a string that may only be null in some versions.
+func getNullableString(dec *decoder.BinaryDecoder, flexible bool, nullable bool) *string {
+	if !nullable {
+		value := getString(dec, flexible)
+		return &value
+	}
+	if flexible {
+		return dec.GetCompactNullableString()
+	}
+	return dec.GetNullableString()
+}
+
+func getBytes(dec *decoder.BinaryDecoder, flexible bool, nullable bool) []byte {
+	switch {
+	case flexible && nullable:
+		return dec.GetCompactNullableBytes()
+	case flexible:
+		return dec.GetCompactBytes()
+	case nullable:
+		return dec.GetNullableBytes()
+	default:
+		return dec.GetNonNullableBytes()
+	}
+}
+
+// getTaggedFields reads a tagged field section, handing each field to get with
+// a decoder limited to the field's value. Unknown tags are skipped.
+func getTaggedFields(dec *decoder.BinaryDecoder, get func(tag uint64, dec *decoder.BinaryDecoder) error) error {
+	for tag, raw := range dec.GetTaggedFields() {
+		if get == nil {
+			continue
+		}
+		value := &decoder.BinaryDecoder{}
+		value.Init(raw)
+		if err := get(tag, value); err != nil {
+			return err
+		}
+		if err := value.Err(); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+func putArrayLen(enc *encoder.BinaryEncoder, length int, flexible bool) {
+	if flexible {
+		enc.PutCompactArrayLen(length)
+	} else {
+		enc.PutArrayLen(length)
+	}
+}
+
+// putNullableArrayLen writes a null array length when the array is nil and the
+// version allows null, and the actual length otherwise.
+func putNullableArrayLen(enc *encoder.BinaryEncoder, isNull bool, length int, flexible bool, nullable bool) {
+	if isNull && nullable {
+		length = -1
+	}
+	putArrayLen(enc, length, flexible)
+}
+
+func putString(enc *encoder.BinaryEncoder, value string, flexible bool) {
+	if flexible {
+		enc.PutCompactString(value)
+	} else {
+		enc.PutString(value)
+	}
+}
+
+// putNullableString writes a string that may only be null in some versions;
+// nil is written as an empty string where null is not allowed.
+func putNullableString(enc *encoder.BinaryEncoder, value *string, flexible bool, nullable bool) {
+	if value == nil && !nullable {
+		value = new(string)
+	}
+	if flexible {
+		enc.PutCompactNullableString(value)
+	} else {
+		enc.PutNullableString(value)
+	}
+}
+
+func putBytes(enc *encoder.BinaryEncoder, value []byte, flexible bool, nullable bool) {
+	switch {
+	case flexible && nullable:
+		enc.PutCompactNullableBytes(value)
+	case flexible:
+		enc.PutCompactBytes(value)
+	case nullable:
+		enc.PutNullableBytes(value)
+	default:
+		enc.PutBytes(value)
+	}
+}
+
+type taggedField struct {
+	tag    uint64
+	encode func(enc *encoder.BinaryEncoder) error
+}
+
+// putTaggedFields writes a tagged field section. Each value is encoded up front
+// since its size precedes it on the wire.
+func putTaggedFields(enc *encoder.BinaryEncoder, fields []taggedField) error {
+	values := make(map[uint64][]byte, len(fields))
+	for _, field := range fields {
+		value := &encoder.BinaryEncoder{}
+		value.Init(nil)
+		if err := field.encode(value); err != nil {
+			return err
+		}
+		values[field.tag] = value.ToBytes()
+	}
+	enc.PutTaggedFields(values)
+	return nil
+}
@@ -0,0 +1,516 @@
+// Code generated by messagegen from FetchResponse.json. DO NOT EDIT.
+
+package messages
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+const (
+	FetchResponseApiKey     int16 = 1
+	FetchResponseMinVersion int16 = 4
+	FetchResponseMaxVersion int16 = 17
+	// FetchResponseFlexibleVersion is the first version using the flexible encoding, or -1 if none does.
+	FetchResponseFlexibleVersion int16 = 12
+)
+
+// FetchResponse is the Fetch response, versions 4 to 17.
+type FetchResponse struct {
+	// The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+	ThrottleTimeMs int32
+	// The top level response error code.
+	ErrorCode int16
+	// The fetch session ID, or 0 if this is not part of a fetch session.
+	SessionId int32
+	// The response topics.
+	Responses []FetchResponseFetchableTopicResponse
+	// Endpoints for all current-leaders enumerated in PartitionData, with errors NOT_LEADER_OR_FOLLOWER & FENCED_LEADER_EPOCH.
+	NodeEndpoints []FetchResponseNodeEndpoint
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *FetchResponse) SetDefaults() {
+}
+
+func (m *FetchResponse) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 12
+	enc.PutInt32(m.ThrottleTimeMs)
+	if version >= 7 {
+		enc.PutInt16(m.ErrorCode)
+	}
+	if version >= 7 {
+		enc.PutInt32(m.SessionId)
+	}
+	putArrayLen(enc, len(m.Responses), flexible)
+	for i := range m.Responses {
+		if err := m.Responses[i].Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	if flexible {
+		var tagged []taggedField
+		if (version >= 16) && len(m.NodeEndpoints) > 0 {
+			tagged = append(tagged, taggedField{tag: 0, encode: func(enc *encoder.BinaryEncoder) error {
+				putArrayLen(enc, len(m.NodeEndpoints), flexible)
+				for i := range m.NodeEndpoints {
+					if err := m.NodeEndpoints[i].Encode(enc, version); err != nil {
+						return err
+					}
+				}
+				return nil
+			}})
+		}
+		if err := putTaggedFields(enc, tagged); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *FetchResponse) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = FetchResponse{}
+	m.SetDefaults()
+	flexible := version >= 12
+	m.ThrottleTimeMs = dec.GetInt32()
+	if version >= 7 {
+		m.ErrorCode = dec.GetInt16()
+	}
+	if version >= 7 {
+		m.SessionId = dec.GetInt32()
+	}
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.Responses = make([]FetchResponseFetchableTopicResponse, n)
+		for i := range m.Responses {
+			if err := m.Responses[i].Decode(dec, version); err != nil {
+				return err
+			}
+		}
+	}
+	if flexible {
+		err := getTaggedFields(dec, func(tag uint64, dec *decoder.BinaryDecoder) error {
+			switch tag {
+			case 0:
+				if version >= 16 {
+					if n := getArrayLen(dec, flexible); n >= 0 {
+						m.NodeEndpoints = make([]FetchResponseNodeEndpoint, n)
+						for i := range m.NodeEndpoints {
+							if err := m.NodeEndpoints[i].Decode(dec, version); err != nil {
+								return err
+							}
+						}
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// FetchResponseFetchableTopicResponse: The response topics.
+type FetchResponseFetchableTopicResponse struct {
+	// The topic name.
+	Topic string
+	// The unique topic ID.
+	TopicId uuid.UUID
+	// The topic partitions.
+	Partitions []FetchResponsePartitionData
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *FetchResponseFetchableTopicResponse) SetDefaults() {
+}
+
+func (m *FetchResponseFetchableTopicResponse) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 12
+	if version <= 12 {
+		putString(enc, m.Topic, flexible)
+	}
+	if version >= 13 {
+		enc.PutRawBytes(m.TopicId[:])
+	}
+	putArrayLen(enc, len(m.Partitions), flexible)
+	for i := range m.Partitions {
+		if err := m.Partitions[i].Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *FetchResponseFetchableTopicResponse) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = FetchResponseFetchableTopicResponse{}
+	m.SetDefaults()
+	flexible := version >= 12
+	if version <= 12 {
+		m.Topic = getString(dec, flexible)
+	}
+	if version >= 13 {
+		m.TopicId = dec.GetUUID()
+	}
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.Partitions = make([]FetchResponsePartitionData, n)
+		for i := range m.Partitions {
+			if err := m.Partitions[i].Decode(dec, version); err != nil {
+				return err
+			}
+		}
+	}
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// FetchResponsePartitionData: The topic partitions.
+type FetchResponsePartitionData struct {
+	// The partition index.
+	PartitionIndex int32
+	// The error code, or 0 if there was no fetch error.
+	ErrorCode int16
+	// The current high water mark.
+	HighWatermark int64
+	// The last stable offset (or LSO) of the partition. This is the last offset such that the state of all transactional records prior to this offset have been decided (ABORTED or COMMITTED).
+	LastStableOffset int64
+	// The current log start offset.
+	LogStartOffset int64
+	// In case divergence is detected based on the `LastFetchedEpoch` and `FetchOffset` in the request, this field indicates the largest epoch and its end offset such that subsequent records are known to diverge.
+	DivergingEpoch FetchResponseEpochEndOffset
+	// The current leader of the partition.
+	CurrentLeader FetchResponseLeaderIdAndEpoch
+	// In the case of fetching an offset less than the LogStartOffset, this is the end offset and epoch that should be used in the FetchSnapshot request.
+	SnapshotId FetchResponseSnapshotId
+	// The aborted transactions.
+	AbortedTransactions []FetchResponseAbortedTransaction
+	// The preferred read replica for the consumer to use on its next fetch request.
+	PreferredReadReplica int32
+	// The record data.
+	Records []byte
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *FetchResponsePartitionData) SetDefaults() {
+	m.LastStableOffset = -1
+	m.LogStartOffset = -1
+	m.DivergingEpoch.SetDefaults()
+	m.CurrentLeader.SetDefaults()
+	m.SnapshotId.SetDefaults()
+	m.PreferredReadReplica = -1
+}
+
+func (m *FetchResponsePartitionData) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 12
+	enc.PutInt32(m.PartitionIndex)
+	enc.PutInt16(m.ErrorCode)
+	enc.PutInt64(m.HighWatermark)
+	enc.PutInt64(m.LastStableOffset)
+	if version >= 5 {
+		enc.PutInt64(m.LogStartOffset)
+	}
+	putNullableArrayLen(enc, m.AbortedTransactions == nil, len(m.AbortedTransactions), flexible, true)
+	for i := range m.AbortedTransactions {
+		if err := m.AbortedTransactions[i].Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	if version >= 11 {
+		enc.PutInt32(m.PreferredReadReplica)
+	}
+	putBytes(enc, m.Records, flexible, true)
+	if flexible {
+		var tagged []taggedField
+		if m.DivergingEpoch != (FetchResponseEpochEndOffset{Epoch: -1, EndOffset: -1}) {
+			tagged = append(tagged, taggedField{tag: 0, encode: func(enc *encoder.BinaryEncoder) error {
+				if err := m.DivergingEpoch.Encode(enc, version); err != nil {
+					return err
+				}
+				return nil
+			}})
+		}
+		if m.CurrentLeader != (FetchResponseLeaderIdAndEpoch{LeaderId: -1, LeaderEpoch: -1}) {
+			tagged = append(tagged, taggedField{tag: 1, encode: func(enc *encoder.BinaryEncoder) error {
+				if err := m.CurrentLeader.Encode(enc, version); err != nil {
+					return err
+				}
+				return nil
+			}})
+		}
+		if m.SnapshotId != (FetchResponseSnapshotId{EndOffset: -1, Epoch: -1}) {
+			tagged = append(tagged, taggedField{tag: 2, encode: func(enc *encoder.BinaryEncoder) error {
+				if err := m.SnapshotId.Encode(enc, version); err != nil {
+					return err
+				}
+				return nil
+			}})
+		}
+		if err := putTaggedFields(enc, tagged); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *FetchResponsePartitionData) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = FetchResponsePartitionData{}
+	m.SetDefaults()
+	flexible := version >= 12
+	m.PartitionIndex = dec.GetInt32()
+	m.ErrorCode = dec.GetInt16()
+	m.HighWatermark = dec.GetInt64()
+	m.LastStableOffset = dec.GetInt64()
+	if version >= 5 {
+		m.LogStartOffset = dec.GetInt64()
+	}
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.AbortedTransactions = make([]FetchResponseAbortedTransaction, n)
+		for i := range m.AbortedTransactions {
+			if err := m.AbortedTransactions[i].Decode(dec, version); err != nil {
+				return err
+			}
+		}
+	}
+	if version >= 11 {
+		m.PreferredReadReplica = dec.GetInt32()
+	}
+	m.Records = getBytes(dec, flexible, true)
+	if flexible {
+		err := getTaggedFields(dec, func(tag uint64, dec *decoder.BinaryDecoder) error {
+			switch tag {
+			case 0:
+				if err := m.DivergingEpoch.Decode(dec, version); err != nil {
+					return err
+				}
+			case 1:
+				if err := m.CurrentLeader.Decode(dec, version); err != nil {
+					return err
+				}
+			case 2:
+				if err := m.SnapshotId.Decode(dec, version); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// FetchResponseEpochEndOffset: In case divergence is detected based on the `LastFetchedEpoch` and `FetchOffset` in the request, this field indicates the largest epoch and its end offset such that subsequent records are known to diverge.
+type FetchResponseEpochEndOffset struct {
+	// The largest epoch.
+	Epoch int32
+	// The end offset of the epoch.
+	EndOffset int64
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *FetchResponseEpochEndOffset) SetDefaults() {
+	m.Epoch = -1
+	m.EndOffset = -1
+}
+
+func (m *FetchResponseEpochEndOffset) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	enc.PutInt32(m.Epoch)
+	enc.PutInt64(m.EndOffset)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *FetchResponseEpochEndOffset) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = FetchResponseEpochEndOffset{}
+	m.SetDefaults()
+	flexible := true
+	m.Epoch = dec.GetInt32()
+	m.EndOffset = dec.GetInt64()
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// FetchResponseLeaderIdAndEpoch: The current leader of the partition.
+type FetchResponseLeaderIdAndEpoch struct {
+	// The ID of the current leader or -1 if the leader is unknown.
+	LeaderId int32
+	// The latest known leader epoch.
+	LeaderEpoch int32
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *FetchResponseLeaderIdAndEpoch) SetDefaults() {
+	m.LeaderId = -1
+	m.LeaderEpoch = -1
+}
+
+func (m *FetchResponseLeaderIdAndEpoch) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	enc.PutInt32(m.LeaderId)
+	enc.PutInt32(m.LeaderEpoch)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *FetchResponseLeaderIdAndEpoch) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = FetchResponseLeaderIdAndEpoch{}
+	m.SetDefaults()
+	flexible := true
+	m.LeaderId = dec.GetInt32()
+	m.LeaderEpoch = dec.GetInt32()
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// FetchResponseSnapshotId: In the case of fetching an offset less than the LogStartOffset, this is the end offset and epoch that should be used in the FetchSnapshot request.
+type FetchResponseSnapshotId struct {
+	// The end offset of the epoch.
+	EndOffset int64
+	// The largest epoch.
+	Epoch int32
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *FetchResponseSnapshotId) SetDefaults() {
+	m.EndOffset = -1
+	m.Epoch = -1
+}
+
+func (m *FetchResponseSnapshotId) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	enc.PutInt64(m.EndOffset)
+	enc.PutInt32(m.Epoch)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *FetchResponseSnapshotId) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = FetchResponseSnapshotId{}
+	m.SetDefaults()
+	flexible := true
+	m.EndOffset = dec.GetInt64()
+	m.Epoch = dec.GetInt32()
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// FetchResponseAbortedTransaction: The aborted transactions.
+type FetchResponseAbortedTransaction struct {
+	// The producer id associated with the aborted transaction.
+	ProducerId int64
+	// The first offset in the aborted transaction.
+	FirstOffset int64
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *FetchResponseAbortedTransaction) SetDefaults() {
+}
+
+func (m *FetchResponseAbortedTransaction) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 12
+	enc.PutInt64(m.ProducerId)
+	enc.PutInt64(m.FirstOffset)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *FetchResponseAbortedTransaction) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = FetchResponseAbortedTransaction{}
+	m.SetDefaults()
+	flexible := version >= 12
+	m.ProducerId = dec.GetInt64()
+	m.FirstOffset = dec.GetInt64()
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// FetchResponseNodeEndpoint: Endpoints for all current-leaders enumerated in PartitionData, with errors NOT_LEADER_OR_FOLLOWER & FENCED_LEADER_EPOCH.
+type FetchResponseNodeEndpoint struct {
+	// The ID of the associated node.
+	NodeId int32
+	// The node's hostname.
+	Host string
+	// The node's port.
+	Port int32
+	// The rack of the node, or null if it has not been assigned to a rack.
+	Rack *string
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *FetchResponseNodeEndpoint) SetDefaults() {
+}
+
+func (m *FetchResponseNodeEndpoint) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	enc.PutInt32(m.NodeId)
+	putString(enc, m.Host, flexible)
+	enc.PutInt32(m.Port)
+	putNullableString(enc, m.Rack, flexible, true)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *FetchResponseNodeEndpoint) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = FetchResponseNodeEndpoint{}
+	m.SetDefaults()
+	flexible := true
+	m.NodeId = dec.GetInt32()
+	m.Host = getString(dec, flexible)
+	m.Port = dec.GetInt32()
+	m.Rack = getNullableString(dec, flexible, true)
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
@@ -0,0 +1,7 @@
+// Package messages holds Kafka protocol message types generated from the JSON
+// schemas in schema/, which are vendored from the Kafka repository
+// (clients/src/main/resources/common/message). Add a schema there and run
+// go generate to get its Go types.
+package messages
+
+//go:generate go run ../messagegen -schemas schema -out .
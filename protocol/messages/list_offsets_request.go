@@ -0,0 +1,171 @@
+// Code generated by messagegen from ListOffsetsRequest.json. DO NOT EDIT.
+
+package messages
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+const (
+	ListOffsetsRequestApiKey     int16 = 2
+	ListOffsetsRequestMinVersion int16 = 1
+	ListOffsetsRequestMaxVersion int16 = 8
+	// ListOffsetsRequestFlexibleVersion is the first version using the flexible encoding, or -1 if none does.
+	ListOffsetsRequestFlexibleVersion int16 = 6
+)
+
+// ListOffsetsRequest is the ListOffsets request, versions 1 to 8.
+type ListOffsetsRequest struct {
+	// The broker ID of the requester, or -1 if this request is being made by a normal consumer.
+	ReplicaId int32
+	// This setting controls the visibility of transactional records. Using READ_UNCOMMITTED (isolation_level = 0) makes all records visible. With READ_COMMITTED (isolation_level = 1), non-transactional and COMMITTED transactional records are visible. To be more concrete, READ_COMMITTED returns all data from offsets smaller than the current LSO (last stable offset), and enables the inclusion of the list of aborted transactions in the result, which allows consumers to discard ABORTED transactional records.
+	IsolationLevel int8
+	// Each topic in the request.
+	Topics []ListOffsetsRequestListOffsetsTopic
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ListOffsetsRequest) SetDefaults() {
+}
+
+func (m *ListOffsetsRequest) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 6
+	enc.PutInt32(m.ReplicaId)
+	if version >= 2 {
+		enc.PutInt8(m.IsolationLevel)
+	}
+	putArrayLen(enc, len(m.Topics), flexible)
+	for i := range m.Topics {
+		if err := m.Topics[i].Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ListOffsetsRequest) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ListOffsetsRequest{}
+	m.SetDefaults()
+	flexible := version >= 6
+	m.ReplicaId = dec.GetInt32()
+	if version >= 2 {
+		m.IsolationLevel = dec.GetInt8()
+	}
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.Topics = make([]ListOffsetsRequestListOffsetsTopic, n)
+		for i := range m.Topics {
+			if err := m.Topics[i].Decode(dec, version); err != nil {
+				return err
+			}
+		}
+	}
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// ListOffsetsRequestListOffsetsTopic: Each topic in the request.
+type ListOffsetsRequestListOffsetsTopic struct {
+	// The topic name.
+	Name string
+	// Each partition in the request.
+	Partitions []ListOffsetsRequestListOffsetsPartition
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ListOffsetsRequestListOffsetsTopic) SetDefaults() {
+}
+
+func (m *ListOffsetsRequestListOffsetsTopic) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 6
+	putString(enc, m.Name, flexible)
+	putArrayLen(enc, len(m.Partitions), flexible)
+	for i := range m.Partitions {
+		if err := m.Partitions[i].Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ListOffsetsRequestListOffsetsTopic) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ListOffsetsRequestListOffsetsTopic{}
+	m.SetDefaults()
+	flexible := version >= 6
+	m.Name = getString(dec, flexible)
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.Partitions = make([]ListOffsetsRequestListOffsetsPartition, n)
+		for i := range m.Partitions {
+			if err := m.Partitions[i].Decode(dec, version); err != nil {
+				return err
+			}
+		}
+	}
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// ListOffsetsRequestListOffsetsPartition: Each partition in the request.
+type ListOffsetsRequestListOffsetsPartition struct {
+	// The partition index.
+	PartitionIndex int32
+	// The current leader epoch.
+	CurrentLeaderEpoch int32
+	// The current timestamp.
+	Timestamp int64
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ListOffsetsRequestListOffsetsPartition) SetDefaults() {
+	m.CurrentLeaderEpoch = -1
+}
+
+func (m *ListOffsetsRequestListOffsetsPartition) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 6
+	enc.PutInt32(m.PartitionIndex)
+	if version >= 4 {
+		enc.PutInt32(m.CurrentLeaderEpoch)
+	}
+	enc.PutInt64(m.Timestamp)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ListOffsetsRequestListOffsetsPartition) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ListOffsetsRequestListOffsetsPartition{}
+	m.SetDefaults()
+	flexible := version >= 6
+	m.PartitionIndex = dec.GetInt32()
+	if version >= 4 {
+		m.CurrentLeaderEpoch = dec.GetInt32()
+	}
+	m.Timestamp = dec.GetInt64()
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
@@ -0,0 +1,159 @@
+package messages
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+type message interface {
+	Encode(enc *encoder.BinaryEncoder, version int16) error
+	Decode(dec *decoder.BinaryDecoder, version int16) error
+}
+
+func encode(t *testing.T, m message, version int16) []byte {
+	t.Helper()
+	enc := &encoder.BinaryEncoder{}
+	enc.Init(nil)
+	if err := m.Encode(enc, version); err != nil {
+		t.Fatal(err)
+	}
+	return enc.Bytes()
+}
+
+func decode(t *testing.T, raw []byte, m message, version int16) {
+	t.Helper()
+	dec := &decoder.BinaryDecoder{}
+	dec.Init(raw)
+	if err := m.Decode(dec, version); err != nil {
+		t.Fatalf("decoding %x: %v", raw, err)
+	}
+	if dec.Remaining() != 0 {
+		t.Fatalf("%d bytes left after decoding %x", dec.Remaining(), raw)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	name := func(name string) *string { return &name }
+	// leader returns a partition with its defaults and the given current leader.
+	leader := func(leaderId, leaderEpoch int32) FetchResponsePartitionData {
+		partition := FetchResponsePartitionData{}
+		partition.SetDefaults()
+		partition.CurrentLeader = FetchResponseLeaderIdAndEpoch{LeaderId: leaderId, LeaderEpoch: leaderEpoch}
+		return partition
+	}
+	tests := []struct {
+		name    string
+		version int16
+		in      message
+		out     message // decoded into; must equal want afterwards
+		want    message // nil when in
+		wantRaw []byte  // when set, the exact encoding
+	}{
+		{
+			// AllowAutoTopicCreation is not in v1 and decodes to its default
+			name: "null array", version: 1,
+			in: &MetadataRequest{AllowAutoTopicCreation: true}, out: &MetadataRequest{},
+			wantRaw: []byte{0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			name: "compact null array", version: 9,
+			in: &MetadataRequest{}, out: &MetadataRequest{},
+			wantRaw: []byte{0, 0, 0, 0, 0},
+		},
+		{
+			// topics only become nullable in v1
+			name: "null array in a version where it is not nullable", version: 0,
+			in: &MetadataRequest{AllowAutoTopicCreation: true}, out: &MetadataRequest{},
+			want:    &MetadataRequest{Topics: []MetadataRequestTopic{}, AllowAutoTopicCreation: true},
+			wantRaw: []byte{0, 0, 0, 0},
+		},
+		{
+			name: "nullable string", version: 12,
+			in:  &MetadataRequest{Topics: []MetadataRequestTopic{{Name: name("foo")}, {}}},
+			out: &MetadataRequest{},
+		},
+		{
+			name: "flexible version", version: 3,
+			in:  &ApiVersionsRequest{ClientSoftwareName: "client", ClientSoftwareVersion: "1.0"},
+			out: &ApiVersionsRequest{},
+			// compact strings are prefixed with their length + 1, then an empty tagged field section
+			wantRaw: append(append([]byte{7}, "client"...), append(append([]byte{4}, "1.0"...), 0)...),
+		},
+		{
+			name: "tagged field", version: 12,
+			in:  func() message { p := leader(2, 3); return &p }(),
+			out: &FetchResponsePartitionData{},
+		},
+		{
+			name: "tagged field at its default", version: 12,
+			in:  func() message { p := leader(-1, -1); return &p }(),
+			out: &FetchResponsePartitionData{},
+		},
+		{
+			name: "tagged field before its version", version: 11,
+			in:   func() message { p := leader(2, 3); return &p }(),
+			out:  &FetchResponsePartitionData{},
+			want: func() message { p := leader(-1, -1); return &p }(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := encode(t, tt.in, tt.version)
+			if tt.wantRaw != nil && !bytes.Equal(raw, tt.wantRaw) {
+				t.Fatalf("encoded %x, want %x", raw, tt.wantRaw)
+			}
+			decode(t, raw, tt.out, tt.version)
+			want := tt.want
+			if want == nil {
+				want = tt.in
+			}
+			if !reflect.DeepEqual(tt.out, want) {
+				t.Fatalf("decoded %+v, want %+v", tt.out, want)
+			}
+		})
+	}
+}
+
+func TestTaggedFieldsAtDefaultsAreLeftOut(t *testing.T) {
+	partition := &FetchResponsePartitionData{}
+	partition.SetDefaults()
+	withDefaults := encode(t, partition, 12)
+	partition.CurrentLeader.LeaderId = 1
+	withLeader := encode(t, partition, 12)
+	if withDefaults[len(withDefaults)-1] != 0 {
+		t.Fatalf("encoded %x, want an empty tagged field section", withDefaults)
+	}
+	// tag, size, then the leader id, leader epoch and the leader's own tagged fields
+	if len(withLeader) != len(withDefaults)+1+1+4+4+1 {
+		t.Fatalf("encoded %x, want the current leader as a tagged field", withLeader)
+	}
+}
+
+func TestUnknownTaggedFieldsAreSkipped(t *testing.T) {
+	raw := []byte{
+		7, 'c', 'l', 'i', 'e', 'n', 't',
+		4, '1', '.', '0',
+		1,          // one tagged field
+		9, 2, 0, 0, // tag 9, two bytes
+	}
+	req := &ApiVersionsRequest{}
+	decode(t, raw, req, 3)
+	if req.ClientSoftwareName != "client" || req.ClientSoftwareVersion != "1.0" {
+		t.Fatalf("decoded %+v", req)
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	raw := encode(t, &MetadataRequest{Topics: []MetadataRequestTopic{{Name: new(string)}}}, 12)
+	for n := 0; n < len(raw); n++ {
+		dec := &decoder.BinaryDecoder{}
+		dec.Init(raw[:n])
+		if err := (&MetadataRequest{}).Decode(dec, 12); err == nil {
+			t.Fatalf("decoded %x, truncated to %d bytes, without an error", raw, n)
+		}
+	}
+}
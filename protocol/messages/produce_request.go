@@ -0,0 +1,162 @@
+// Code generated by messagegen from ProduceRequest.json. DO NOT EDIT.
+
+package messages
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+const (
+	ProduceRequestApiKey     int16 = 0
+	ProduceRequestMinVersion int16 = 3
+	ProduceRequestMaxVersion int16 = 11
+	// ProduceRequestFlexibleVersion is the first version using the flexible encoding, or -1 if none does.
+	ProduceRequestFlexibleVersion int16 = 9
+)
+
+// ProduceRequest is the Produce request, versions 3 to 11.
+type ProduceRequest struct {
+	// The transactional ID, or null if the producer is not transactional.
+	TransactionalId *string
+	// The number of acknowledgments the producer requires the leader to have received before considering a request complete. Allowed values: 0 for no acknowledgments, 1 for only the leader and -1 for the full ISR.
+	Acks int16
+	// The timeout to await a response in milliseconds.
+	TimeoutMs int32
+	// Each topic to produce to.
+	TopicData []ProduceRequestTopicProduceData
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ProduceRequest) SetDefaults() {
+}
+
+func (m *ProduceRequest) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 9
+	putNullableString(enc, m.TransactionalId, flexible, true)
+	enc.PutInt16(m.Acks)
+	enc.PutInt32(m.TimeoutMs)
+	putArrayLen(enc, len(m.TopicData), flexible)
+	for i := range m.TopicData {
+		if err := m.TopicData[i].Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ProduceRequest) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ProduceRequest{}
+	m.SetDefaults()
+	flexible := version >= 9
+	m.TransactionalId = getNullableString(dec, flexible, true)
+	m.Acks = dec.GetInt16()
+	m.TimeoutMs = dec.GetInt32()
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.TopicData = make([]ProduceRequestTopicProduceData, n)
+		for i := range m.TopicData {
+			if err := m.TopicData[i].Decode(dec, version); err != nil {
+				return err
+			}
+		}
+	}
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// ProduceRequestTopicProduceData: Each topic to produce to.
+type ProduceRequestTopicProduceData struct {
+	// The topic name.
+	Name string
+	// Each partition to produce to.
+	PartitionData []ProduceRequestPartitionProduceData
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ProduceRequestTopicProduceData) SetDefaults() {
+}
+
+func (m *ProduceRequestTopicProduceData) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 9
+	putString(enc, m.Name, flexible)
+	putArrayLen(enc, len(m.PartitionData), flexible)
+	for i := range m.PartitionData {
+		if err := m.PartitionData[i].Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ProduceRequestTopicProduceData) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ProduceRequestTopicProduceData{}
+	m.SetDefaults()
+	flexible := version >= 9
+	m.Name = getString(dec, flexible)
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.PartitionData = make([]ProduceRequestPartitionProduceData, n)
+		for i := range m.PartitionData {
+			if err := m.PartitionData[i].Decode(dec, version); err != nil {
+				return err
+			}
+		}
+	}
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// ProduceRequestPartitionProduceData: Each partition to produce to.
+type ProduceRequestPartitionProduceData struct {
+	// The partition index.
+	Index int32
+	// The record data to be produced.
+	Records []byte
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ProduceRequestPartitionProduceData) SetDefaults() {
+}
+
+func (m *ProduceRequestPartitionProduceData) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 9
+	enc.PutInt32(m.Index)
+	putBytes(enc, m.Records, flexible, true)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ProduceRequestPartitionProduceData) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ProduceRequestPartitionProduceData{}
+	m.SetDefaults()
+	flexible := version >= 9
+	m.Index = dec.GetInt32()
+	m.Records = getBytes(dec, flexible, true)
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
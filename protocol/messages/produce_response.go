@@ -0,0 +1,377 @@
+// Code generated by messagegen from ProduceResponse.json. DO NOT EDIT.
+
+package messages
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+const (
+	ProduceResponseApiKey     int16 = 0
+	ProduceResponseMinVersion int16 = 3
+	ProduceResponseMaxVersion int16 = 11
+	// ProduceResponseFlexibleVersion is the first version using the flexible encoding, or -1 if none does.
+	ProduceResponseFlexibleVersion int16 = 9
+)
+
+// ProduceResponse is the Produce response, versions 3 to 11.
+type ProduceResponse struct {
+	// Each produce response.
+	Responses []ProduceResponseTopicProduceResponse
+	// The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+	ThrottleTimeMs int32
+	// Endpoints for all current-leaders enumerated in PartitionProduceResponses, with errors NOT_LEADER_OR_FOLLOWER.
+	NodeEndpoints []ProduceResponseNodeEndpoint
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ProduceResponse) SetDefaults() {
+}
+
+func (m *ProduceResponse) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 9
+	putArrayLen(enc, len(m.Responses), flexible)
+	for i := range m.Responses {
+		if err := m.Responses[i].Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	enc.PutInt32(m.ThrottleTimeMs)
+	if flexible {
+		var tagged []taggedField
+		if (version >= 10) && len(m.NodeEndpoints) > 0 {
+			tagged = append(tagged, taggedField{tag: 0, encode: func(enc *encoder.BinaryEncoder) error {
+				putArrayLen(enc, len(m.NodeEndpoints), flexible)
+				for i := range m.NodeEndpoints {
+					if err := m.NodeEndpoints[i].Encode(enc, version); err != nil {
+						return err
+					}
+				}
+				return nil
+			}})
+		}
+		if err := putTaggedFields(enc, tagged); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ProduceResponse) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ProduceResponse{}
+	m.SetDefaults()
+	flexible := version >= 9
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.Responses = make([]ProduceResponseTopicProduceResponse, n)
+		for i := range m.Responses {
+			if err := m.Responses[i].Decode(dec, version); err != nil {
+				return err
+			}
+		}
+	}
+	m.ThrottleTimeMs = dec.GetInt32()
+	if flexible {
+		err := getTaggedFields(dec, func(tag uint64, dec *decoder.BinaryDecoder) error {
+			switch tag {
+			case 0:
+				if version >= 10 {
+					if n := getArrayLen(dec, flexible); n >= 0 {
+						m.NodeEndpoints = make([]ProduceResponseNodeEndpoint, n)
+						for i := range m.NodeEndpoints {
+							if err := m.NodeEndpoints[i].Decode(dec, version); err != nil {
+								return err
+							}
+						}
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// ProduceResponseTopicProduceResponse: Each produce response.
+type ProduceResponseTopicProduceResponse struct {
+	// The topic name.
+	Name string
+	// Each partition that we produced to within the topic.
+	PartitionResponses []ProduceResponsePartitionProduceResponse
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ProduceResponseTopicProduceResponse) SetDefaults() {
+}
+
+func (m *ProduceResponseTopicProduceResponse) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 9
+	putString(enc, m.Name, flexible)
+	putArrayLen(enc, len(m.PartitionResponses), flexible)
+	for i := range m.PartitionResponses {
+		if err := m.PartitionResponses[i].Encode(enc, version); err != nil {
+			return err
+		}
+	}
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ProduceResponseTopicProduceResponse) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ProduceResponseTopicProduceResponse{}
+	m.SetDefaults()
+	flexible := version >= 9
+	m.Name = getString(dec, flexible)
+	if n := getArrayLen(dec, flexible); n >= 0 {
+		m.PartitionResponses = make([]ProduceResponsePartitionProduceResponse, n)
+		for i := range m.PartitionResponses {
+			if err := m.PartitionResponses[i].Decode(dec, version); err != nil {
+				return err
+			}
+		}
+	}
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// ProduceResponsePartitionProduceResponse: Each partition that we produced to within the topic.
+type ProduceResponsePartitionProduceResponse struct {
+	// The partition index.
+	Index int32
+	// The error code, or 0 if there was no error.
+	ErrorCode int16
+	// The base offset.
+	BaseOffset int64
+	// The timestamp returned by broker after appending the messages. If CreateTime is used for the topic, the timestamp will be -1.  If LogAppendTime is used for the topic, the timestamp will be the broker local time when the messages are appended.
+	LogAppendTimeMs int64
+	// The log start offset.
+	LogStartOffset int64
+	// The batch indices of records that caused the batch to be dropped.
+	RecordErrors []ProduceResponseBatchIndexAndErrorMessage
+	// The global error message summarizing the common root cause of the records that caused the batch to be dropped.
+	ErrorMessage *string
+	// The leader broker that the producer should use for future requests.
+	CurrentLeader ProduceResponseLeaderIdAndEpoch
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ProduceResponsePartitionProduceResponse) SetDefaults() {
+	m.LogAppendTimeMs = -1
+	m.LogStartOffset = -1
+	m.CurrentLeader.SetDefaults()
+}
+
+func (m *ProduceResponsePartitionProduceResponse) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 9
+	enc.PutInt32(m.Index)
+	enc.PutInt16(m.ErrorCode)
+	enc.PutInt64(m.BaseOffset)
+	enc.PutInt64(m.LogAppendTimeMs)
+	if version >= 5 {
+		enc.PutInt64(m.LogStartOffset)
+	}
+	if version >= 8 {
+		putArrayLen(enc, len(m.RecordErrors), flexible)
+		for i := range m.RecordErrors {
+			if err := m.RecordErrors[i].Encode(enc, version); err != nil {
+				return err
+			}
+		}
+	}
+	if version >= 8 {
+		putNullableString(enc, m.ErrorMessage, flexible, version >= 8)
+	}
+	if flexible {
+		var tagged []taggedField
+		if (version >= 10) && m.CurrentLeader != (ProduceResponseLeaderIdAndEpoch{LeaderId: -1, LeaderEpoch: -1}) {
+			tagged = append(tagged, taggedField{tag: 0, encode: func(enc *encoder.BinaryEncoder) error {
+				if err := m.CurrentLeader.Encode(enc, version); err != nil {
+					return err
+				}
+				return nil
+			}})
+		}
+		if err := putTaggedFields(enc, tagged); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ProduceResponsePartitionProduceResponse) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ProduceResponsePartitionProduceResponse{}
+	m.SetDefaults()
+	flexible := version >= 9
+	m.Index = dec.GetInt32()
+	m.ErrorCode = dec.GetInt16()
+	m.BaseOffset = dec.GetInt64()
+	m.LogAppendTimeMs = dec.GetInt64()
+	if version >= 5 {
+		m.LogStartOffset = dec.GetInt64()
+	}
+	if version >= 8 {
+		if n := getArrayLen(dec, flexible); n >= 0 {
+			m.RecordErrors = make([]ProduceResponseBatchIndexAndErrorMessage, n)
+			for i := range m.RecordErrors {
+				if err := m.RecordErrors[i].Decode(dec, version); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if version >= 8 {
+		m.ErrorMessage = getNullableString(dec, flexible, version >= 8)
+	}
+	if flexible {
+		err := getTaggedFields(dec, func(tag uint64, dec *decoder.BinaryDecoder) error {
+			switch tag {
+			case 0:
+				if version >= 10 {
+					if err := m.CurrentLeader.Decode(dec, version); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// ProduceResponseBatchIndexAndErrorMessage: The batch indices of records that caused the batch to be dropped.
+type ProduceResponseBatchIndexAndErrorMessage struct {
+	// The batch index of the record that caused the batch to be dropped.
+	BatchIndex int32
+	// The error message of the record that caused the batch to be dropped.
+	BatchIndexErrorMessage *string
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ProduceResponseBatchIndexAndErrorMessage) SetDefaults() {
+}
+
+func (m *ProduceResponseBatchIndexAndErrorMessage) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := version >= 9
+	enc.PutInt32(m.BatchIndex)
+	putNullableString(enc, m.BatchIndexErrorMessage, flexible, true)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ProduceResponseBatchIndexAndErrorMessage) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ProduceResponseBatchIndexAndErrorMessage{}
+	m.SetDefaults()
+	flexible := version >= 9
+	m.BatchIndex = dec.GetInt32()
+	m.BatchIndexErrorMessage = getNullableString(dec, flexible, true)
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// ProduceResponseLeaderIdAndEpoch: The leader broker that the producer should use for future requests.
+type ProduceResponseLeaderIdAndEpoch struct {
+	// The ID of the current leader or -1 if the leader is unknown.
+	LeaderId int32
+	// The latest known leader epoch.
+	LeaderEpoch int32
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ProduceResponseLeaderIdAndEpoch) SetDefaults() {
+	m.LeaderId = -1
+	m.LeaderEpoch = -1
+}
+
+func (m *ProduceResponseLeaderIdAndEpoch) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	enc.PutInt32(m.LeaderId)
+	enc.PutInt32(m.LeaderEpoch)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ProduceResponseLeaderIdAndEpoch) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ProduceResponseLeaderIdAndEpoch{}
+	m.SetDefaults()
+	flexible := true
+	m.LeaderId = dec.GetInt32()
+	m.LeaderEpoch = dec.GetInt32()
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
+
+// ProduceResponseNodeEndpoint: Endpoints for all current-leaders enumerated in PartitionProduceResponses, with errors NOT_LEADER_OR_FOLLOWER.
+type ProduceResponseNodeEndpoint struct {
+	// The ID of the associated node.
+	NodeId int32
+	// The node's hostname.
+	Host string
+	// The node's port.
+	Port int32
+	// The rack of the node, or null if it has not been assigned to a rack.
+	Rack *string
+}
+
+// SetDefaults sets every field with a non-zero default to that default.
+func (m *ProduceResponseNodeEndpoint) SetDefaults() {
+}
+
+func (m *ProduceResponseNodeEndpoint) Encode(enc *encoder.BinaryEncoder, version int16) error {
+	flexible := true
+	enc.PutInt32(m.NodeId)
+	putString(enc, m.Host, flexible)
+	enc.PutInt32(m.Port)
+	putNullableString(enc, m.Rack, flexible, true)
+	if flexible {
+		if err := putTaggedFields(enc, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ProduceResponseNodeEndpoint) Decode(dec *decoder.BinaryDecoder, version int16) error {
+	*m = ProduceResponseNodeEndpoint{}
+	m.SetDefaults()
+	flexible := true
+	m.NodeId = dec.GetInt32()
+	m.Host = getString(dec, flexible)
+	m.Port = dec.GetInt32()
+	m.Rack = getNullableString(dec, flexible, true)
+	if flexible {
+		if err := getTaggedFields(dec, nil); err != nil {
+			return err
+		}
+	}
+	return dec.Err()
+}
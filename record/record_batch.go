@@ -0,0 +1,372 @@
+// Package record implements the v2 record batch format shared by produce
+// requests, fetch responses and partition logs.
+package record
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+var (
+	errInvalidRecordHeaderCount = errors.New("invalid record header count")
+	errInvalidRecordLength      = errors.New("record length does not match its fields")
+)
+
+// Errors returned by RecordBatch.Decode for batches that fail validation. They
+// correspond to the CORRUPT_MESSAGE and UNSUPPORTED_FOR_MESSAGE_FORMAT error codes.
+var (
+	ErrCorruptMessage              = errors.New("corrupt record batch")
+	ErrUnsupportedForMessageFormat = errors.New("unsupported record batch format")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type RecordBatch struct {
+	BaseOffset           int64
+	BatchLength          int32
+	PartitionLeaderEpoch int32
+	Magic                int8
+	CRC                  int32
+	Attributes           int16
+	LastOffsetDelta      int32
+	FirstTimestamp       int64
+	MaxTimestamp         int64
+	ProducerId           int64
+	ProducerEpoch        int16
+	BaseSequence         int32
+	Records              []Record
+}
+
+// Decode reads a v2 record batch, checking its magic, its CRC-32C, that its
+// records take up exactly BatchLength and that their offset deltas increase up
+// to LastOffsetDelta. Failures wrap ErrCorruptMessage or
+// ErrUnsupportedForMessageFormat. The records of compressed batches are not
+// decoded, leaving Records nil.
+func (r *RecordBatch) Decode(dec *decoder.BinaryDecoder) error {
+	r.BaseOffset = dec.GetInt64()
+	r.BatchLength = dec.GetInt32()
+	r.PartitionLeaderEpoch = dec.GetInt32()
+	r.Magic = dec.GetInt8()
+	r.CRC = dec.GetInt32()
+	if err := dec.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrCorruptMessage, err)
+	}
+	// the magic is at the same position in every format, unlike the fields after it
+	if r.Magic != 2 {
+		return fmt.Errorf("%w: magic %d", ErrUnsupportedForMessageFormat, r.Magic)
+	}
+
+	// the CRC covers the rest of the batch, from the attributes on
+	length := int(r.BatchLength) - (batchAttributesOffset - BatchOverhead)
+	if length < BatchHeaderLength-batchAttributesOffset || length > dec.Remaining() {
+		return fmt.Errorf("%w: batch length %d does not fit the %d bytes of the batch",
+			ErrCorruptMessage, r.BatchLength, dec.Remaining()+batchAttributesOffset-BatchOverhead)
+	}
+	data := dec.GetBytes(length)
+	if checksum := crc32.Checksum(data, castagnoli); uint32(r.CRC) != checksum {
+		return fmt.Errorf("%w: CRC %08x does not match the checksum %08x", ErrCorruptMessage, uint32(r.CRC), checksum)
+	}
+
+	dec = &decoder.BinaryDecoder{}
+	dec.Init(data)
+	r.Attributes = dec.GetInt16()
+	r.LastOffsetDelta = dec.GetInt32()
+	r.FirstTimestamp = dec.GetInt64()
+	r.MaxTimestamp = dec.GetInt64()
+	r.ProducerId = dec.GetInt64()
+	r.ProducerEpoch = dec.GetInt16()
+	r.BaseSequence = dec.GetInt32()
+	recordCount := dec.GetInt32()
+	if r.LastOffsetDelta < 0 {
+		return fmt.Errorf("%w: last offset delta %d", ErrCorruptMessage, r.LastOffsetDelta)
+	}
+	if IsCompressed(r.Attributes) {
+		r.Records = nil
+		return nil
+	}
+	// every record takes at least a byte, which bounds the allocation
+	if recordCount < 0 || int(recordCount) > dec.Remaining() {
+		return fmt.Errorf("%w: record count %d with %d bytes of records", ErrCorruptMessage, recordCount, dec.Remaining())
+	}
+	r.Records = make([]Record, recordCount)
+	for i := range r.Records {
+		record := Record{}
+		if err := record.Decode(dec); err != nil {
+			return fmt.Errorf("%w: record %d: %w", ErrCorruptMessage, i, err)
+		}
+		// compaction leaves gaps, but offsets still increase up to the last one
+		if record.OffsetDelta < 0 || record.OffsetDelta > int64(r.LastOffsetDelta) || i > 0 && record.OffsetDelta <= r.Records[i-1].OffsetDelta {
+			return fmt.Errorf("%w: record %d has offset delta %d", ErrCorruptMessage, i, record.OffsetDelta)
+		}
+		r.Records[i] = record
+	}
+	if dec.Remaining() > 0 {
+		return fmt.Errorf("%w: %d bytes left after the records", ErrCorruptMessage, dec.Remaining())
+	}
+	return nil
+}
+
+// ValidateBatch checks an encoded record batch as RecordBatch.Decode does, and
+// that the offset deltas of an uncompressed batch run from 0 to its last
+// offset delta without gaps, as in batches written by producers.
+func ValidateBatch(batch []byte) error {
+	dec := &decoder.BinaryDecoder{}
+	dec.Init(batch)
+	r := RecordBatch{}
+	if err := r.Decode(dec); err != nil {
+		return err
+	}
+	if IsCompressed(r.Attributes) {
+		return nil
+	}
+	if int(r.LastOffsetDelta) != len(r.Records)-1 {
+		return fmt.Errorf("%w: last offset delta %d with %d records", ErrCorruptMessage, r.LastOffsetDelta, len(r.Records))
+	}
+	for i, record := range r.Records {
+		if record.OffsetDelta != int64(i) {
+			return fmt.Errorf("%w: record %d has offset delta %d", ErrCorruptMessage, i, record.OffsetDelta)
+		}
+	}
+	return nil
+}
+
+// Encode writes the batch with its length and CRC computed from the records,
+// which keep their offset deltas.
+func (r *RecordBatch) Encode(enc *encoder.BinaryEncoder) error {
+	startOffset := enc.Offset()
+
+	enc.PutInt64(r.BaseOffset)
+	batchLengthStartOffset := enc.Offset()
+	enc.PutInt32(0) // placeholder
+	enc.PutInt32(r.PartitionLeaderEpoch)
+	enc.PutInt8(r.Magic)
+	crcStartOffset := enc.Offset()
+	enc.PutInt32(0) // placeholder
+	crcEndOffset := enc.Offset()
+	enc.PutInt16(r.Attributes)
+	enc.PutInt32(r.LastOffsetDelta)
+	enc.PutInt64(r.FirstTimestamp)
+	enc.PutInt64(r.MaxTimestamp)
+	enc.PutInt64(r.ProducerId)
+	enc.PutInt16(r.ProducerEpoch)
+	enc.PutInt32(r.BaseSequence)
+	enc.PutInt32(int32(len(r.Records)))
+	for _, record := range r.Records {
+		if err := record.Encode(enc); err != nil {
+			return err
+		}
+	}
+
+	batchLength := enc.Offset() - 12 - startOffset // 8 bytes for BaseOffset and 4 bytes for BatchLength
+	enc.PutInt32At(int32(batchLength), batchLengthStartOffset)
+
+	// Calculate CRC
+	crcData := enc.Bytes()[crcEndOffset:enc.Offset()]
+	crcCheckSum := crc32.Checksum(crcData, castagnoli)
+	enc.PutInt32At(int32(crcCheckSum), crcStartOffset)
+	return nil
+}
+
+type Record struct {
+	Length         int64 // signed varint
+	Attributes     int8
+	TimestampDelta int64  // signed varint
+	OffsetDelta    int64  // signed varint
+	KeyLength      int64  // signed varint (-1 if null)
+	Key            []byte // nullable
+	ValueLength    int64  // signed varint
+	Value          []byte // nullable
+	Headers        []RecordHeader
+}
+
+func (r *Record) Decode(dec *decoder.BinaryDecoder) error {
+	r.Length = dec.GetSignedVarint()
+	start := dec.Remaining()
+	r.Attributes = dec.GetInt8()
+	r.TimestampDelta = dec.GetSignedVarint()
+	r.OffsetDelta = dec.GetSignedVarint()
+	r.KeyLength = dec.GetSignedVarint()
+	if r.KeyLength != -1 {
+		r.Key = dec.GetBytes(int(r.KeyLength))
+	}
+	r.ValueLength = dec.GetSignedVarint()
+	if r.ValueLength != -1 {
+		r.Value = dec.GetBytes(int(r.ValueLength))
+	}
+	recordHeadersLen := dec.GetSignedVarint()
+	if recordHeadersLen < 0 || recordHeadersLen > int64(dec.Remaining()) {
+		return errInvalidRecordHeaderCount
+	}
+	r.Headers = make([]RecordHeader, recordHeadersLen)
+	for i := range r.Headers {
+		recordHeader := RecordHeader{}
+		if err := recordHeader.Decode(dec); err != nil {
+			return err
+		}
+		r.Headers[i] = recordHeader
+	}
+	if err := dec.Err(); err != nil {
+		return err
+	}
+	if int64(start-dec.Remaining()) != r.Length {
+		return errInvalidRecordLength
+	}
+	return nil
+}
+
+func (r *Record) Encode(enc *encoder.BinaryEncoder) error {
+	enc.PutVarint(r.Length)
+	enc.PutInt8(r.Attributes)
+	enc.PutVarint(r.TimestampDelta)
+	enc.PutVarint(r.OffsetDelta)
+	enc.PutVarint(r.KeyLength)
+	if r.KeyLength != -1 {
+		enc.PutRawBytes(r.Key)
+	}
+	enc.PutVarint(r.ValueLength)
+	if r.ValueLength != -1 {
+		enc.PutRawBytes(r.Value)
+	}
+	enc.PutVarint(int64(len(r.Headers)))
+	for _, recordHeader := range r.Headers {
+		if err := recordHeader.Encode(enc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Record) GetEncodedLength() int64 {
+	enc := encoder.BinaryEncoder{}
+	enc.Init(nil)
+
+	enc.PutInt8(r.Attributes)
+	enc.PutVarint(r.TimestampDelta)
+	enc.PutVarint(r.OffsetDelta)
+	enc.PutVarint(r.KeyLength)
+	if r.KeyLength != -1 {
+		enc.PutRawBytes(r.Key)
+	}
+	enc.PutVarint(r.ValueLength)
+	if r.ValueLength != -1 {
+		enc.PutRawBytes(r.Value)
+	}
+	enc.PutVarint(int64(len(r.Headers)))
+	for _, header := range r.Headers {
+		header.Encode(&enc)
+	}
+
+	return int64(enc.Offset())
+}
+
+type RecordHeader struct {
+	Key   string
+	Value []byte // nullable
+}
+
+func (r *RecordHeader) Decode(dec *decoder.BinaryDecoder) error {
+	keyLength := dec.GetSignedVarint()
+	r.Key = string(dec.GetBytes(int(keyLength)))
+	valueLength := dec.GetSignedVarint()
+	if valueLength != -1 {
+		r.Value = dec.GetBytes(int(valueLength))
+	}
+	return dec.Err()
+}
+
+func (r *RecordHeader) Encode(enc *encoder.BinaryEncoder) error {
+	enc.PutVarint(int64(len(r.Key)))
+	enc.PutRawBytes([]byte(r.Key))
+	if r.Value == nil {
+		enc.PutVarint(-1)
+		return nil
+	}
+	enc.PutVarint(int64(len(r.Value)))
+	enc.PutRawBytes(r.Value)
+	return nil
+}
+
+// Attribute bits of a record batch.
+const (
+	compressionCodecMask = 0x07
+	controlBatchFlag     = 0x20
+)
+
+// Byte offsets of the fixed-size fields at the start of an encoded record batch.
+// https://kafka.apache.org/documentation/#recordbatch
+const (
+	batchLengthOffset          = 8
+	batchAttributesOffset      = 21
+	batchLastOffsetDeltaOffset = 23
+	batchMaxTimestampOffset    = 35
+)
+
+const (
+	// BatchOverhead is the size of the base offset and length fields, which the
+	// batch length does not count.
+	BatchOverhead = 12
+	// BatchHeaderLength is the size of a record batch without its records.
+	BatchHeaderLength = 61
+)
+
+// SplitBatches splits raw log or request bytes into encoded record batches
+// without decoding the records. It stops at the first incomplete batch and
+// returns the number of bytes that were consumed.
+func SplitBatches(data []byte) ([][]byte, int) {
+	var batches [][]byte
+	position := 0
+	for len(data)-position >= BatchHeaderLength {
+		size := BatchSize(data[position:])
+		if size < BatchHeaderLength || position+size > len(data) {
+			break
+		}
+		batches = append(batches, data[position:position+size])
+		position += size
+	}
+	return batches, position
+}
+
+// BatchSize returns the size of the whole batch, which only needs the first
+// BatchOverhead bytes of it. A negative batch length yields a size below
+// BatchHeaderLength.
+func BatchSize(batch []byte) int {
+	return BatchOverhead + int(int32(binary.BigEndian.Uint32(batch[batchLengthOffset:])))
+}
+
+func BatchBaseOffset(batch []byte) int64 {
+	return int64(binary.BigEndian.Uint64(batch))
+}
+
+func SetBatchBaseOffset(batch []byte, baseOffset int64) {
+	binary.BigEndian.PutUint64(batch, uint64(baseOffset))
+}
+
+func BatchLastOffset(batch []byte) int64 {
+	lastOffsetDelta := int32(binary.BigEndian.Uint32(batch[batchLastOffsetDeltaOffset:]))
+	return BatchBaseOffset(batch) + int64(lastOffsetDelta)
+}
+
+func BatchMaxTimestamp(batch []byte) int64 {
+	return int64(binary.BigEndian.Uint64(batch[batchMaxTimestampOffset:]))
+}
+
+func BatchAttributes(batch []byte) int16 {
+	return int16(binary.BigEndian.Uint16(batch[batchAttributesOffset:]))
+}
+
+// IsCompressed reports whether the records of a batch with the given
+// attributes are compressed.
+func IsCompressed(attributes int16) bool {
+	return attributes&compressionCodecMask != 0
+}
+
+// IsControlBatch reports whether a batch with the given attributes holds
+// transaction markers rather than records.
+func IsControlBatch(attributes int16) bool {
+	return attributes&controlBatchFlag != 0
+}
@@ -0,0 +1,232 @@
+package record
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/protocol/decoder"
+	"github.com/codecrafters-io/kafka-starter-go/protocol/encoder"
+)
+
+// testBatch returns an uncompressed batch of three records, as a producer
+// would send it.
+func testBatch() RecordBatch {
+	batch := RecordBatch{
+		Magic:           2,
+		LastOffsetDelta: 2,
+		FirstTimestamp:  1000,
+		MaxTimestamp:    1002,
+		ProducerId:      -1,
+		ProducerEpoch:   -1,
+		BaseSequence:    -1,
+	}
+	for i, value := range []string{"a", "bb", "ccc"} {
+		record := Record{
+			TimestampDelta: int64(i),
+			OffsetDelta:    int64(i),
+			KeyLength:      -1,
+			ValueLength:    int64(len(value)),
+			Value:          []byte(value),
+			Headers:        []RecordHeader{{Key: "h", Value: []byte("v")}},
+		}
+		record.Length = record.GetEncodedLength()
+		batch.Records = append(batch.Records, record)
+	}
+	return batch
+}
+
+func encodeTestBatch(t *testing.T, batch RecordBatch) []byte {
+	t.Helper()
+	enc := encoder.BinaryEncoder{}
+	enc.Init(nil)
+	if err := batch.Encode(&enc); err != nil {
+		t.Fatal(err)
+	}
+	return enc.Bytes()
+}
+
+// resign recomputes the length and CRC of a batch changed after encoding.
+func resign(raw []byte) []byte {
+	binary.BigEndian.PutUint32(raw[batchLengthOffset:], uint32(len(raw)-BatchOverhead))
+	binary.BigEndian.PutUint32(raw[17:], crc32.Checksum(raw[batchAttributesOffset:], castagnoli))
+	return raw
+}
+
+func TestDecodeAndValidateBatch(t *testing.T) {
+	tests := []struct {
+		name string
+		// modify changes the batch before it is encoded, and corrupt after
+		modify          func(batch *RecordBatch)
+		corrupt         func(raw []byte) []byte
+		wantDecodeErr   error
+		wantValidateErr error
+	}{
+		{name: "valid"},
+		{
+			name:            "bad CRC",
+			corrupt:         func(raw []byte) []byte { raw[len(raw)-1] ^= 0xff; return raw },
+			wantDecodeErr:   ErrCorruptMessage,
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			name:            "old magic",
+			corrupt:         func(raw []byte) []byte { raw[16] = 1; return raw }, // magic
+			wantDecodeErr:   ErrUnsupportedForMessageFormat,
+			wantValidateErr: ErrUnsupportedForMessageFormat,
+		},
+		{
+			name: "batch length shorter than the header",
+			corrupt: func(raw []byte) []byte {
+				binary.BigEndian.PutUint32(raw[batchLengthOffset:], 10)
+				return raw
+			},
+			wantDecodeErr:   ErrCorruptMessage,
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			name:            "batch length past the end",
+			corrupt:         func(raw []byte) []byte { return raw[:len(raw)-1] },
+			wantDecodeErr:   ErrCorruptMessage,
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			name: "negative record count",
+			corrupt: func(raw []byte) []byte {
+				binary.BigEndian.PutUint32(raw[57:], 0xffffffff) // record count
+				return resign(raw)
+			},
+			wantDecodeErr:   ErrCorruptMessage,
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			name: "record count larger than the records",
+			corrupt: func(raw []byte) []byte {
+				binary.BigEndian.PutUint32(raw[57:], 1000) // record count
+				return resign(raw)
+			},
+			wantDecodeErr:   ErrCorruptMessage,
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			name:            "bytes after the records",
+			corrupt:         func(raw []byte) []byte { return resign(append(raw, 0, 0, 0)) },
+			wantDecodeErr:   ErrCorruptMessage,
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			name:            "record length that does not match",
+			modify:          func(batch *RecordBatch) { batch.Records[0].Length-- },
+			wantDecodeErr:   ErrCorruptMessage,
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			name:            "negative last offset delta",
+			modify:          func(batch *RecordBatch) { batch.LastOffsetDelta = -15 },
+			wantDecodeErr:   ErrCorruptMessage,
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			name: "offset deltas going back",
+			modify: func(batch *RecordBatch) {
+				batch.Records[1].OffsetDelta, batch.Records[2].OffsetDelta = 2, 1
+			},
+			wantDecodeErr:   ErrCorruptMessage,
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			name:            "offset delta past the last one",
+			modify:          func(batch *RecordBatch) { batch.Records[2].OffsetDelta = 5 },
+			wantDecodeErr:   ErrCorruptMessage,
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			name:            "negative offset delta",
+			modify:          func(batch *RecordBatch) { batch.Records[0].OffsetDelta = -1 },
+			wantDecodeErr:   ErrCorruptMessage,
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			// as left by compaction
+			name:            "gap in offset deltas",
+			modify:          func(batch *RecordBatch) { batch.Records = batch.Records[1:] },
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			name:            "last offset delta past the records",
+			modify:          func(batch *RecordBatch) { batch.LastOffsetDelta = 5 },
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			name:            "last offset delta before the records end",
+			modify:          func(batch *RecordBatch) { batch.LastOffsetDelta = 1 },
+			wantDecodeErr:   ErrCorruptMessage,
+			wantValidateErr: ErrCorruptMessage,
+		},
+		{
+			name:   "compressed records are not decoded",
+			modify: func(batch *RecordBatch) { batch.Attributes = 1; batch.Records[0].Length = 1000 },
+		},
+		{
+			name: "compressed with a negative last offset delta",
+			modify: func(batch *RecordBatch) {
+				batch.Attributes = 1
+				batch.LastOffsetDelta = -1
+			},
+			wantDecodeErr:   ErrCorruptMessage,
+			wantValidateErr: ErrCorruptMessage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := testBatch()
+			if tt.modify != nil {
+				tt.modify(&batch)
+			}
+			raw := encodeTestBatch(t, batch)
+			if tt.corrupt != nil {
+				raw = tt.corrupt(raw)
+			}
+
+			dec := &decoder.BinaryDecoder{}
+			dec.Init(raw)
+			decoded := RecordBatch{}
+			if err := decoded.Decode(dec); !errors.Is(err, tt.wantDecodeErr) {
+				t.Fatalf("Decode returned %v, want %v", err, tt.wantDecodeErr)
+			}
+			if err := ValidateBatch(raw); !errors.Is(err, tt.wantValidateErr) {
+				t.Fatalf("ValidateBatch returned %v, want %v", err, tt.wantValidateErr)
+			}
+		})
+	}
+}
+
+func TestRecordBatchRoundTrip(t *testing.T) {
+	batch := testBatch()
+	batch.BaseOffset = 42
+	raw := encodeTestBatch(t, batch)
+	if BatchSize(raw) != len(raw) || BatchBaseOffset(raw) != 42 || BatchLastOffset(raw) != 44 || BatchMaxTimestamp(raw) != 1002 {
+		t.Fatalf("header fields of %x do not match the batch", raw)
+	}
+
+	dec := &decoder.BinaryDecoder{}
+	dec.Init(raw)
+	decoded := RecordBatch{}
+	if err := decoded.Decode(dec); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Records) != len(batch.Records) {
+		t.Fatalf("decoded %d records, want %d", len(decoded.Records), len(batch.Records))
+	}
+	for i, record := range decoded.Records {
+		want := batch.Records[i]
+		if record.OffsetDelta != want.OffsetDelta || record.TimestampDelta != want.TimestampDelta ||
+			string(record.Value) != string(want.Value) || record.Key != nil || len(record.Headers) != 1 {
+			t.Fatalf("record %d decoded as %+v, want %+v", i, record, want)
+		}
+	}
+	if reencoded := encodeTestBatch(t, decoded); string(reencoded) != string(raw) {
+		t.Fatalf("re-encoding gave %x, want %x", reencoded, raw)
+	}
+}